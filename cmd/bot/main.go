@@ -1,6 +1,7 @@
 package bot
 
 import (
+	"context"
 	"net/http"
 	_ "net/http/pprof"
 	"os"
@@ -46,7 +47,32 @@ func run(cmd *cobra.Command, args []string) {
 	}
 
 	store := storage.NewBadgerHoldStorage("data")
-	manager := game.NewManager(store, cfg.MaxBet, cfg.MinDeal, cfg.Timeout)
+	if err := store.OpenLedger(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("failed to open ledger")
+	}
+	if err := store.BuildTotals(context.Background()); err != nil {
+		log.Fatal().Err(err).Msg("failed to build player totals")
+	}
+	manager := game.NewManager(store, cfg.MaxBet, cfg.MinDeal, cfg.Timeout, game.ShoeConfig{
+		Decks:       cfg.Shoe.Decks,
+		Penetration: cfg.Shoe.Penetration,
+		Persist:     cfg.Shoe.Persist,
+		Shuffler:    game.CryptoShuffler{},
+	})
+	manager.SetBettingWindow(cfg.BettingWindow)
+	manager.SetFaucet(game.FaucetConfig{
+		Daily:           cfg.Faucet.Daily,
+		StreakBonus:     cfg.Faucet.StreakBonus,
+		MaxStreak:       cfg.Faucet.MaxStreak,
+		ReliefAmount:    cfg.Faucet.ReliefAmount,
+		ReliefThreshold: cfg.Faucet.ReliefThreshold,
+		ReliefAfter:     cfg.Faucet.ReliefAfter,
+		MaxReliefs:      cfg.Faucet.MaxReliefs,
+		ReliefWindow:    cfg.Faucet.ReliefWindow,
+	})
+	if err := manager.LoadRules(context.Background(), cfg.RulesFile); err != nil {
+		log.Fatal().Err(err).Msg("failed to load rules")
+	}
 
 	// listen to interrupt signal i.e Ctrl+C
 	ch := make(chan os.Signal, 1)
@@ -54,6 +80,7 @@ func run(cmd *cobra.Command, args []string) {
 	go func() {
 		<-ch
 		log.Info().Msg("shutting down bot")
+		manager.Snapshot(context.Background())
 		// if _, err := bot.Close(); err != nil {
 		// 	log.Err(err).Msg("failed to stop bot")
 		// }
@@ -64,6 +91,7 @@ func run(cmd *cobra.Command, args []string) {
 	}()
 
 	bh := telegram.NewHandler(manager, bot, store)
+	bh.SetTopChat(cfg.TopChatID)
 	log.Info().Msg("bot started")
 	if err := bh.Start(); err != nil {
 		log.Fatal().Err(err).Msg("failed to start bot handler")
@@ -9,6 +9,34 @@ type BotConfig struct {
 	MaxBet   uint64         `split_words:"true" default:"200"`
 	MinDeal  uint64         `split_words:"true" default:"1000"`
 	Timeout  time.Duration  `split_words:"true" default:"1m"`
+	// BettingWindow is how long new games wait for bets before they are dealt automatically, 0 to deal by hand
+	BettingWindow time.Duration `split_words:"true" default:"0"`
+	RulesFile     string        `split_words:"true"`
+	// TopChatID is the chat where the leaderboard of the day is pinned and refreshed after every game, 0 to disable
+	TopChatID int64        `split_words:"true"`
+	Shoe      ShoeConfig   `split_words:"true"`
+	Faucet    FaucetConfig `split_words:"true"`
+}
+
+// FaucetConfig sets the daily bonus and the relief of broke players, a zero amount disables them,
+// both are disabled by default
+type FaucetConfig struct {
+	Daily       int64 `split_words:"true" default:"0"`
+	StreakBonus int64 `split_words:"true" default:"20"`
+	MaxStreak   int   `split_words:"true" default:"7"`
+	// ReliefAmount is granted to a player whose balance stayed under ReliefThreshold for ReliefAfter,
+	// at most MaxReliefs times within ReliefWindow
+	ReliefAmount    int64         `split_words:"true" default:"0"`
+	ReliefThreshold int64         `split_words:"true" default:"50"`
+	ReliefAfter     time.Duration `split_words:"true" default:"24h"`
+	MaxReliefs      int           `split_words:"true" default:"3"`
+	ReliefWindow    time.Duration `split_words:"true" default:"168h"`
+}
+
+type ShoeConfig struct {
+	Decks       int     `split_words:"true" default:"1"`
+	Penetration float64 `split_words:"true" default:"0.75"`
+	Persist     bool    `split_words:"true"`
 }
 
 type TelegramConfig struct {
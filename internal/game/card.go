@@ -59,11 +59,12 @@ func (cs Cards) IsDoubleBlackJack() bool {
 	return len(cs) == 2 && cs[0].Value() == 1 && cs[1].Value() == 1
 }
 
-func (cs Cards) IsHighFive() bool {
-	return len(cs) == 5 && cs.Value() <= 21
+func (cs Cards) IsHighFive(rule *Rule) bool {
+	return len(cs) == rule.orDefault().HighFiveCards && cs.Value(rule) <= 21
 }
 
-func (cs Cards) Value() int {
+func (cs Cards) Value(rule *Rule) int {
+	rule = rule.orDefault()
 	aCnt := 0
 	sum := 0
 	for _, c := range cs {
@@ -76,19 +77,19 @@ func (cs Cards) Value() int {
 	if aCnt == 0 {
 		return sum
 	}
-	if sum >= 12 || len(cs) >= 4 {
+	if len(cs) >= rule.AceOneFromCards {
 		return sum + aCnt
 	}
-	if sum+11+(aCnt-1) <= 21 {
-		return sum + 11 + (aCnt - 1)
-	}
-	if sum+10+(aCnt-1) <= 21 {
-		return sum + 10 + (aCnt - 1)
+	// only one ace can take a high value, the others count as 1
+	for _, v := range rule.AceValues {
+		if sum+v+(aCnt-1) <= 21 {
+			return sum + v + (aCnt - 1)
+		}
 	}
 	return sum + aCnt
 }
 
-func (cs Cards) String(censor bool, isDealer ...bool) string {
+func (cs Cards) String(rule *Rule, censor bool, isDealer bool) string {
 	if censor {
 		return strings.Repeat("**, ", len(cs)-1) + " ** (" + strconv.Itoa(len(cs)) + " lá)"
 	}
@@ -96,25 +97,26 @@ func (cs Cards) String(censor bool, isDealer ...bool) string {
 	for i := range cs {
 		s[i] = cs[i].String()
 	}
-	return strings.Join(s, ", ") + " (" + cs.TypeString(isDealer...) + ")"
+	return strings.Join(s, ", ") + " (" + cs.TypeString(rule, isDealer) + ")"
 }
 
-func (cs Cards) Type(isDealer ...bool) model.ResultType {
+func (cs Cards) Type(rule *Rule, isDealer bool) model.ResultType {
+	rule = rule.orDefault()
 	if cs.IsDoubleBlackJack() {
 		return model.TypeDoubleBlackJack
 	} else if cs.IsBlackJack() {
 		return model.TypeBlackJack
-	} else if cs.IsHighFive() {
+	} else if cs.IsHighFive(rule) {
 		return model.TypeHighFive
 	}
-	val := cs.Value()
-	min := 16
-	if len(isDealer) > 0 && isDealer[0] {
-		min = 15
+	val := cs.Value(rule)
+	min := rule.PlayerMinValue
+	if isDealer {
+		min = rule.DealerMinValue
 	}
 	if val < min {
 		return model.TypeTooLow
-	} else if val >= 28 {
+	} else if val >= rule.TooHighValue {
 		return model.TypeTooHigh
 	} else if val > 21 {
 		return model.TypeBusted
@@ -122,21 +124,21 @@ func (cs Cards) Type(isDealer ...bool) model.ResultType {
 	return model.TypeNormal
 }
 
-func (cs Cards) TypeString(isDealer ...bool) string {
-	switch cs.Type(isDealer...) {
+func (cs Cards) TypeString(rule *Rule, isDealer bool) string {
+	switch cs.Type(rule, isDealer) {
 	case model.TypeHighFive:
-		return fmt.Sprintf("ngũ linh: %d điểm ⚡️", cs.Value())
+		return fmt.Sprintf("ngũ linh: %d điểm ⚡️", cs.Value(rule))
 	case model.TypeBusted:
-		return fmt.Sprintf("toang: %d điểm 💥", cs.Value())
+		return fmt.Sprintf("toang: %d điểm 💥", cs.Value(rule))
 	case model.TypeBlackJack:
 		return "xì lác ⚡️"
 	case model.TypeDoubleBlackJack:
 		return "xì bàn ⚡️"
 	case model.TypeTooLow:
-		return fmt.Sprintf("chưa đủ tẩy: %d điểm", cs.Value())
+		return fmt.Sprintf("chưa đủ tẩy: %d điểm", cs.Value(rule))
 	case model.TypeTooHigh:
-		return fmt.Sprintf("đền: %d điểm", cs.Value())
+		return fmt.Sprintf("đền: %d điểm", cs.Value(rule))
 	default:
-		return fmt.Sprintf("%d điểm", cs.Value())
+		return fmt.Sprintf("%d điểm", cs.Value(rule))
 	}
 }
@@ -2,6 +2,8 @@ package game
 
 import (
 	"testing"
+
+	"github.com/psucodervn/verixilac/internal/model"
 )
 
 func TestCard_String(t *testing.T) {
@@ -68,7 +70,7 @@ func TestCards_Value(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := tt.cs.Value(); got != tt.want {
+			if got := tt.cs.Value(&DefaultRule); got != tt.want {
 				t.Errorf("Value() = %v, want %v", got, tt.want)
 			}
 		})
@@ -114,3 +116,59 @@ func TestCards_IsDoubleBlackJack(t *testing.T) {
 		})
 	}
 }
+
+func TestCards_Type(t *testing.T) {
+	custom := Rule{ID: "x", Name: "x", PlayerMinValue: 17, DealerMinValue: 14, TooHighValue: 26, HighFiveCards: 4}
+	_ = custom.Validate()
+	tests := []struct {
+		name     string
+		rule     *Rule
+		cs       Cards
+		isDealer bool
+		want     model.ResultType
+	}{
+		{cs: NewCards(5, 8), want: model.TypeTooLow},
+		{cs: NewCards(5, 8), isDealer: true, want: model.TypeNormal},
+		{cs: NewCards(9, 10, 8), want: model.TypeTooHigh},
+		{cs: NewCards(1, 1, 1, 1, 2), want: model.TypeHighFive},
+		{rule: &custom, cs: NewCards(5, 9), want: model.TypeTooLow},
+		{rule: &custom, cs: NewCards(4, 7), isDealer: true, want: model.TypeTooLow},
+		{rule: &custom, cs: NewCards(4, 9), isDealer: true, want: model.TypeNormal},
+		{rule: &custom, cs: NewCards(9, 10, 5), want: model.TypeTooHigh},
+		{rule: &custom, cs: NewCards(1, 1, 1, 2), want: model.TypeHighFive},
+		{rule: &custom, cs: NewCards(1, 1, 1, 1, 2), want: model.TypeTooLow},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cs.Type(tt.rule, tt.isDealer); got != tt.want {
+				t.Errorf("Type() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCards_ValueWithAceRule(t *testing.T) {
+	oneOnly := Rule{ID: "x", Name: "x", AceValues: []int{}, AceOneFromCards: 3}
+	_ = oneOnly.Validate()
+	elevenOnly := Rule{ID: "y", Name: "y", AceValues: []int{11}, AceOneFromCards: 5}
+	_ = elevenOnly.Validate()
+	tests := []struct {
+		name string
+		rule *Rule
+		cs   Cards
+		want int
+	}{
+		{rule: &oneOnly, cs: NewCards(0, 5), want: 7},
+		{rule: &elevenOnly, cs: NewCards(0, 0, 8), want: 21},
+		{rule: &elevenOnly, cs: NewCards(0, 0, 9), want: 12},
+		{rule: &elevenOnly, cs: NewCards(2, 2, 3, 0), want: 21},
+		{rule: &DefaultRule, cs: NewCards(0, 0, 9), want: 21},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.cs.Value(tt.rule); got != tt.want {
+				t.Errorf("Value() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
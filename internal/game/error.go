@@ -26,4 +26,29 @@ var (
 	ErrNotTimeout              = errors.New("chưa quá thời gian")
 	ErrCannotCreateGame        = errors.New("không thể tạo ván mới")
 	ErrServerMaintenance       = errors.New("server đang bảo trì")
+	ErrCannotCreateRoom        = errors.New("không thể tạo phòng mới")
+	ErrNotRoomOwner            = errors.New("bạn không phải chủ phòng")
+	ErrShoeEmpty               = errors.New("hết bài trong shoe")
+	ErrTooManyPlayers          = errors.New("bàn đã đủ người chơi")
+	ErrSeedMismatch            = errors.New("seed không khớp với mã cam kết")
+	ErrSeedTooLong             = errors.New("seed quá dài")
+	ErrInvalidSeed             = errors.New("seed không hợp lệ")
+	ErrCannotAddSeed           = errors.New("ván này không hỗ trợ góp seed")
+	ErrGameNotFinished         = errors.New("ván chưa kết thúc")
+	ErrGameFinished            = errors.New("ván đã kết thúc")
+	ErrProofNotFound           = errors.New("không tìm thấy dữ liệu xác minh của ván")
+	ErrDealerBankFull          = errors.New("nhà cái không đủ tiền cân thêm cược")
+	ErrNoPlayerToReveal        = errors.New("không còn ai để lật bài")
+	ErrRevealNotAllowed        = errors.New("luật không cho lật bài người này lúc này")
+	ErrPlayerAmbiguous         = errors.New("có nhiều người chơi trùng tên, hãy dùng ID")
+	ErrPlayYourself            = errors.New("không thể chọn chính mình")
+	ErrAlreadyFollowing        = errors.New("bạn đã theo dõi người này rồi")
+	ErrNotFollowing            = errors.New("bạn chưa theo dõi người này")
+	ErrSeasonNotFound          = errors.New("không tìm thấy mùa giải")
+	ErrInvalidAmount           = errors.New("số tiền không hợp lệ")
+	ErrInvalidDueDate          = errors.New("hạn trả phải sau thời điểm hiện tại")
+	ErrNoDebt                  = errors.New("bạn không nợ người này")
+	ErrFaucetDisabled          = errors.New("chưa mở quà hằng ngày")
+	ErrFaucetBlocked           = errors.New("bạn đã bị chặn nhận quà")
+	ErrDailyClaimed            = errors.New("hôm nay bạn đã nhận quà rồi, hãy quay lại vào ngày mai")
 )
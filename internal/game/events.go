@@ -0,0 +1,180 @@
+package game
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/psucodervn/verixilac/internal/model"
+	"github.com/psucodervn/verixilac/internal/stringer"
+)
+
+// FoldEvents rebuilds a game by applying its events in order, players are looked up by findPlayer
+func FoldEvents(events []model.GameEvent, findPlayer func(id string) *model.Player) (*Game, error) {
+	var g *Game
+	for _, ev := range events {
+		var err error
+		if g, err = applyEvent(g, ev, findPlayer); err != nil {
+			return nil, err
+		}
+	}
+	if g == nil {
+		return nil, ErrGameNotFound
+	}
+	return g, nil
+}
+
+func applyEvent(g *Game, ev model.GameEvent, findPlayer func(id string) *model.Player) (*Game, error) {
+	if ev.Type == model.EventNew {
+		rule := findRule(ev.RuleID)
+		g = &Game{
+			id:         ev.GameID,
+			createdAt:  ev.CreatedAt,
+			rule:       rule,
+			dealer:     NewPlayerInGame(eventPlayer(ev.PlayerID, findPlayer), rule, 0, true),
+			currentIdx: -1,
+		}
+		g.eventSeq = ev.Seq
+		return g, nil
+	}
+	if g == nil {
+		return nil, fmt.Errorf("sự kiện %s #%d trước khi tạo ván", ev.Type, ev.Seq)
+	}
+
+	var pg *PlayerInGame
+	if len(ev.PlayerID) > 0 {
+		pg = g.findPlayer(ev.PlayerID)
+		if pg == nil && ev.Type != model.EventBet {
+			return nil, fmt.Errorf("sự kiện %s #%d: %w", ev.Type, ev.Seq, ErrPlayerNotFound)
+		}
+	}
+
+	// participants play in turn after the deal, the first turn has no event of its own
+	if ev.Type == model.EventHit || ev.Type == model.EventStand || ev.Type == model.EventPass {
+		if g.currentIdx < 0 {
+			if _, err := g.PlayerNext(); err != nil {
+				return nil, err
+			}
+		}
+		pg.SetLastHit(ev.CreatedAt.Unix())
+	}
+
+	switch ev.Type {
+	case model.EventBet:
+		if pg == nil {
+			pg = NewPlayerInGame(eventPlayer(ev.PlayerID, findPlayer), g.rule, ev.Amount, false)
+			g.players = append(g.players, pg)
+		} else {
+			pg.SetBet(uint64(ev.Amount))
+		}
+	case model.EventLeave:
+		if err := g.RemovePlayer(ev.PlayerID); err != nil {
+			return nil, err
+		}
+	case model.EventDeal:
+		pg.cards = NewCards(ev.Cards...)
+		if g.Status() == Betting {
+			g.status.Store(uint32(Playing))
+			g.doneCnt.Store(uint32(len(g.players)))
+		}
+	case model.EventHit:
+		for _, id := range ev.Cards {
+			pg.AddCard(Card{id: id})
+		}
+	case model.EventStand:
+		if err := pg.Stand(); err != nil {
+			return nil, err
+		}
+		if !pg.IsDealer() {
+			if _, err := g.PlayerNext(); err != nil {
+				return nil, err
+			}
+		}
+	case model.EventPass:
+		pg.SetStatus(PlayerStood)
+		if pg.IsDealer() {
+			g.status.Store(uint32(Finished))
+		} else if _, err := g.PlayerNext(); err != nil {
+			return nil, err
+		}
+	case model.EventCompare:
+		if _, err := g.Done(pg, true); err != nil {
+			return nil, err
+		}
+	case model.EventFinish, model.EventCancel:
+		g.status.Store(uint32(Finished))
+	default:
+		return nil, fmt.Errorf("không rõ sự kiện %s #%d", ev.Type, ev.Seq)
+	}
+
+	// the game methods above record their own events, they are already in the log
+	g.events = nil
+	g.eventSeq = ev.Seq
+	return g, nil
+}
+
+func eventPlayer(id string, findPlayer func(id string) *model.Player) *model.Player {
+	if p := findPlayer(id); p != nil {
+		return p
+	}
+	return &model.Player{ID: id, TelegramID: id, Name: id}
+}
+
+// ReplayText renders the game step by step from its events
+func ReplayText(events []model.GameEvent, findPlayer func(id string) *model.Player) (string, error) {
+	bf := bytes.NewBuffer(nil)
+	var g *Game
+	for _, ev := range events {
+		var err error
+		if g, err = applyEvent(g, ev, findPlayer); err != nil {
+			return "", err
+		}
+
+		var pg *PlayerInGame
+		name := ""
+		if len(ev.PlayerID) > 0 {
+			if pg = g.findPlayer(ev.PlayerID); pg != nil {
+				name = pg.Name
+			} else {
+				name = eventPlayer(ev.PlayerID, findPlayer).Name
+			}
+		}
+
+		bf.WriteString(fmt.Sprintf("%d. `%s` ", ev.Seq, ev.CreatedAt.Format("15:04:05")))
+		switch ev.Type {
+		case model.EventNew:
+			bf.WriteString(fmt.Sprintf("Nhà cái `%s` mở ván, luật `%s`", name, g.rule.Name))
+		case model.EventBet:
+			bf.WriteString(fmt.Sprintf("`%s` cược %s", name, stringer.FormatCurrency(ev.Amount)))
+		case model.EventLeave:
+			bf.WriteString(fmt.Sprintf("`%s` rời ván", name))
+		case model.EventDeal:
+			bf.WriteString(fmt.Sprintf("Chia bài cho `%s`: %s", name, pg.CardsInfo()))
+		case model.EventHit:
+			bf.WriteString(fmt.Sprintf("`%s` rút %s: %s", name, cardNames(ev.Cards), pg.CardsInfo()))
+		case model.EventStand:
+			bf.WriteString(fmt.Sprintf("`%s` dừng rút", name))
+		case model.EventPass:
+			bf.WriteString(fmt.Sprintf("`%s` bị qua lượt", name))
+		case model.EventCompare:
+			bf.WriteString(fmt.Sprintf("Nhà cái xét `%s`: %s (%s)", name, pg.CardsInfo(), stringer.FormatCurrency(-ev.Amount)))
+		case model.EventFinish:
+			bf.WriteString(fmt.Sprintf("Kết thúc ván, nhà cái: %s (%s)", g.dealer.CardsInfo(), stringer.FormatCurrency(g.dealer.Reward())))
+		case model.EventCancel:
+			bf.WriteString("Ván bị huỷ")
+		}
+		bf.WriteString("\n")
+	}
+	if g == nil {
+		return "", ErrGameNotFound
+	}
+	return bf.String(), nil
+}
+
+func cardNames(ids []int) string {
+	s := make([]string, len(ids))
+	for i, id := range ids {
+		s[i] = Card{id: id}.String()
+	}
+	return strings.Join(s, ", ")
+}
@@ -0,0 +1,180 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// playGame plays a whole game, participants and the dealer hit until 17
+func playGame(t *testing.T, m *Manager) *Game {
+	t.Helper()
+	ctx := context.Background()
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	g, err := m.NewGame(dealer, "")
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	for _, id := range []string{"2", "3", "4"} {
+		p := &model.Player{ID: id, Name: "Player #" + id, Balance: 1000}
+		_, _ = m.JoinRoom(ctx, p, r.ID())
+		if err := m.PlayerBet(ctx, g.ID(), p, 10); err != nil {
+			t.Fatalf("PlayerBet() error = %v", err)
+		}
+	}
+	_ = m.PlayerBet(ctx, g.ID(), &model.Player{ID: "4"}, 0)
+	if _, err := m.Deal(ctx, g.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+	if err := m.Start(ctx, g); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if g.Finished() {
+		// an early blackjack finishes the game at the start
+		return g
+	}
+
+	for !g.Finished() {
+		pg := g.CurrentPlaying()
+		for pg.CanHit() && pg.Value() < 17 {
+			if err := m.PlayerHit(ctx, g, pg); err != nil {
+				t.Fatalf("PlayerHit() error = %v", err)
+			}
+		}
+		if !pg.IsDealer() {
+			if err := m.PlayerStand(ctx, g, pg); err != nil {
+				t.Fatalf("PlayerStand() error = %v", err)
+			}
+			continue
+		}
+		// hands the rule does not let the dealer reveal are left to the turn timer
+		for _, p := range g.PlayersInGame() {
+			if _, err := m.Compare(ctx, g, p); err != nil && err != ErrPlayerIsDone && err != ErrRevealNotAllowed {
+				t.Fatalf("Compare() error = %v", err)
+			}
+		}
+		break
+	}
+	err = m.FinishGame(ctx, g, false)
+	if errors.Is(err, ErrRevealNotAllowed) {
+		err = m.FinishGame(ctx, g, true)
+	}
+	if err != nil {
+		t.Fatalf("FinishGame() error = %v", err)
+	}
+	return g
+}
+
+func TestFoldEvents(t *testing.T) {
+	for seed := int64(1); seed <= 20; seed++ {
+		store := newFakeStore()
+		m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Shuffler: NewSeededShuffler(seed)})
+		g := playGame(t, m)
+
+		events := store.events[g.ID()]
+		if got := events[len(events)-1].Type; got != model.EventFinish {
+			t.Fatalf("seed %d: last event = %v, want %v", seed, got, model.EventFinish)
+		}
+		fg, err := FoldEvents(events, func(id string) *model.Player {
+			return m.findPlayer(context.Background(), id)
+		})
+		if err != nil {
+			t.Fatalf("seed %d: FoldEvents() error = %v", seed, err)
+		}
+		if !fg.Finished() {
+			t.Errorf("seed %d: folded game status = %v, want %v", seed, fg.Status(), Finished)
+		}
+		if got, want := len(fg.PlayersInGame()), len(g.PlayersInGame()); got != want {
+			t.Fatalf("seed %d: folded game has %d players, want %d", seed, got, want)
+		}
+		for _, pg := range g.AllPlayers() {
+			fpg := fg.findPlayer(pg.ID)
+			if fpg.CardsInfo() != pg.CardsInfo() || fpg.Reward() != pg.Reward() || fpg.BetAmount() != pg.BetAmount() {
+				t.Errorf("seed %d: folded %s = %s %d %d, want %s %d %d", seed, pg.Name,
+					fpg.CardsInfo(), fpg.Reward(), fpg.BetAmount(), pg.CardsInfo(), pg.Reward(), pg.BetAmount())
+			}
+		}
+	}
+}
+
+func TestManager_ReplayGame(t *testing.T) {
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Shuffler: NewSeededShuffler(1)})
+	g := playGame(t, m)
+
+	res, err := m.ReplayGame(context.Background(), g.ID())
+	if err != nil {
+		t.Fatalf("ReplayGame() error = %v", err)
+	}
+	for _, s := range []string{"mở ván", "cược", "rời ván", "Chia bài", "Kết thúc ván"} {
+		if !strings.Contains(res, s) {
+			t.Errorf("ReplayGame() = %q, want to contain %q", res, s)
+		}
+	}
+	if _, err := m.ReplayGame(context.Background(), "unknown"); err != ErrGameNotFound {
+		t.Errorf("ReplayGame() error = %v, want %v", err, ErrGameNotFound)
+	}
+
+	ctx := context.Background()
+	dealer := &model.Player{ID: "5", Name: "Player #5", Balance: 1000}
+	p := &model.Player{ID: "6", Name: "Player #6", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	_, _ = m.JoinRoom(ctx, p, r.ID())
+	running, _ := m.NewGame(dealer, "")
+	if err := m.PlayerBet(ctx, running.ID(), p, 10); err != nil {
+		t.Fatalf("PlayerBet() error = %v", err)
+	}
+	if _, err := m.Deal(ctx, running.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+	if _, err := m.ReplayGame(ctx, running.ID()); err != ErrGameNotFinished {
+		t.Errorf("ReplayGame() while playing error = %v, want %v", err, ErrGameNotFinished)
+	}
+}
+
+func TestManager_GameRecord(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Shuffler: NewSeededShuffler(1)})
+	g := playGame(t, m)
+
+	r, ok := store.records[g.ID()]
+	if !ok {
+		t.Fatalf("game record of %s is not saved", g.ID())
+	}
+	if r.Status != model.GameFinished || r.DealerID != "1" || r.RuleID != g.Rule().ID || r.RoomID != g.RoomID() {
+		t.Errorf("game record = %+v", r)
+	}
+	if r.StartedAt.IsZero() || r.EndedAt.Before(r.StartedAt) {
+		t.Errorf("game record times = %v - %v", r.StartedAt, r.EndedAt)
+	}
+	if got, want := r.PlayerIDs, []string{"1", "2", "3"}; strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("game record players = %v, want %v", got, want)
+	}
+	sum := int64(0)
+	for _, p := range r.Participants {
+		sum += p.Reward
+		if !p.IsDealer && p.Bet != 10 {
+			t.Errorf("game record bet of %s = %d, want %d", p.PlayerID, p.Bet, 10)
+		}
+	}
+	if sum != 0 {
+		t.Errorf("game record rewards sum = %d, want 0", sum)
+	}
+
+	// cancelled game
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	g2, _ := m.NewGame(dealer, "")
+	_ = m.PlayerBet(ctx, g2.ID(), &model.Player{ID: "2", Balance: 1000}, 20)
+	if _, err := m.CancelGame(ctx, g2.ID()); err != nil {
+		t.Fatalf("CancelGame() error = %v", err)
+	}
+	if r := store.records[g2.ID()]; r.Status != model.GameCancelled || len(r.Participants) != 2 || r.Participants[1].Bet != 20 {
+		t.Errorf("cancelled game record = %+v", r)
+	}
+}
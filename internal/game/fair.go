@@ -0,0 +1,43 @@
+package game
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// Provably fair shuffle: the server seed is committed by its sha256 before betting opens,
+// players may add their own client seeds while betting, the shoe is shuffled by
+// DeriveSeed(server seed, game id, client seeds) and the server seed is revealed when the game ends.
+
+const MaxClientSeedLength = 64
+
+// Commitment is the hex sha256 of the server seed
+func Commitment(serverSeed []byte) string {
+	h := sha256.Sum256(serverSeed)
+	return hex.EncodeToString(h[:])
+}
+
+// DeriveSeed returns HMAC-SHA256(server seed, "gameID|seed1|seed2|...") which is the seed to shuffle the shoe
+func DeriveSeed(serverSeed []byte, gameID string, clientSeeds []model.ClientSeed) []byte {
+	msg := make([]string, 0, len(clientSeeds)+1)
+	msg = append(msg, gameID)
+	for _, cs := range clientSeeds {
+		msg = append(msg, cs.Seed)
+	}
+	mac := hmac.New(sha256.New, serverSeed)
+	mac.Write([]byte(strings.Join(msg, "|")))
+	return mac.Sum(nil)
+}
+
+// VerifyShuffle checks the revealed server seed against the commitment
+// and re-derives the order of the cards in the shoe of the game
+func VerifyShuffle(proof *model.GameProof) (Cards, error) {
+	if Commitment(proof.ServerSeed) != proof.Commitment {
+		return nil, ErrSeedMismatch
+	}
+	return ShuffleCards(proof.Decks, DeriveSeed(proof.ServerSeed, proof.GameID, proof.ClientSeeds)), nil
+}
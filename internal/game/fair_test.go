@@ -0,0 +1,111 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestVerifyShuffle(t *testing.T) {
+	serverSeed := NewSeededShuffler(1).NewSeed()
+	clientSeeds := []model.ClientSeed{{PlayerID: "2", Seed: "hello"}, {PlayerID: "3", Seed: "world"}}
+	proof := &model.GameProof{
+		GameID:      "g1",
+		Commitment:  Commitment(serverSeed),
+		ServerSeed:  serverSeed,
+		ClientSeeds: clientSeeds,
+		Decks:       1,
+	}
+	want := ShuffleCards(1, DeriveSeed(serverSeed, "g1", clientSeeds))
+
+	tests := []struct {
+		name    string
+		modify  func(p *model.GameProof)
+		wantErr error
+		same    bool
+	}{
+		{name: "valid", modify: func(p *model.GameProof) {}, same: true},
+		{name: "wrong server seed", modify: func(p *model.GameProof) { p.ServerSeed = NewSeededShuffler(2).NewSeed() }, wantErr: ErrSeedMismatch},
+		{name: "other client seeds", modify: func(p *model.GameProof) { p.ClientSeeds = clientSeeds[:1] }},
+		{name: "other game", modify: func(p *model.GameProof) { p.GameID = "g2" }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := *proof
+			tt.modify(&p)
+			got, err := VerifyShuffle(&p)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("VerifyShuffle() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if same := got.String(nil, false, false) == want.String(nil, false, false); same != tt.same {
+				t.Errorf("VerifyShuffle() same order = %v, want %v", same, tt.same)
+			}
+		})
+	}
+}
+
+func TestManager_VerifyGame(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Shuffler: NewSeededShuffler(3)})
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	p := &model.Player{ID: "2", Name: "Player #2", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	_, _ = m.JoinRoom(ctx, p, r.ID())
+	g, _ := m.NewGame(dealer, "")
+	commitment := g.Commitment()
+	if len(commitment) == 0 {
+		t.Fatalf("Commitment() is empty")
+	}
+
+	if _, err := m.AddClientSeed(ctx, p, "lucky"); err != nil {
+		t.Fatalf("AddClientSeed() error = %v", err)
+	}
+	if _, err := m.AddClientSeed(ctx, p, "bad`seed"); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("AddClientSeed() error = %v, want %v", err, ErrInvalidSeed)
+	}
+	if _, err := m.AddClientSeed(ctx, p, "a|b"); !errors.Is(err, ErrInvalidSeed) {
+		t.Errorf("AddClientSeed() with the separator error = %v, want %v", err, ErrInvalidSeed)
+	}
+	if err := m.PlayerBet(ctx, g.ID(), p, 50); err != nil {
+		t.Fatalf("PlayerBet() error = %v", err)
+	}
+	if _, err := m.Deal(ctx, g.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+	if _, err := m.AddClientSeed(ctx, p, "late"); !errors.Is(err, ErrGameAlreadyStarted) {
+		t.Errorf("AddClientSeed() after deal error = %v, want %v", err, ErrGameAlreadyStarted)
+	}
+	if _, err := m.VerifyGame(ctx, g.ID()); !errors.Is(err, ErrGameNotFinished) {
+		t.Errorf("VerifyGame() while playing error = %v, want %v", err, ErrGameNotFinished)
+	}
+
+	if _, err := m.CancelGame(ctx, g.ID()); err != nil {
+		t.Fatalf("CancelGame() error = %v", err)
+	}
+	if _, err := m.VerifyGame(ctx, g.ID()); err != nil {
+		t.Fatalf("VerifyGame() error = %v", err)
+	}
+
+	proof := store.proofs[g.ID()]
+	if proof.Commitment != commitment {
+		t.Errorf("proof commitment = %v, want %v", proof.Commitment, commitment)
+	}
+	cards, err := VerifyShuffle(&proof)
+	if err != nil {
+		t.Fatalf("VerifyShuffle() error = %v", err)
+	}
+	// dealer first, one card each round
+	if got, want := g.Dealer().Cards()[0], cards[0]; got != want {
+		t.Errorf("dealer first card = %v, want %v", got, want)
+	}
+	if got, want := g.FindPlayer(p.ID).Cards()[1], cards[3]; got != want {
+		t.Errorf("player second card = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,91 @@
+package game
+
+import (
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// FaucetConfig sets the free money granted to the players, a zero amount disables the grant
+type FaucetConfig struct {
+	// Daily is the bonus of /daily, StreakBonus is added for every consecutive day claimed before up to MaxStreak days
+	Daily       int64
+	StreakBonus int64
+	MaxStreak   int
+
+	// ReliefAmount is granted to a player whose balance stayed under ReliefThreshold for ReliefAfter,
+	// at most MaxReliefs times within ReliefWindow
+	ReliefAmount    int64
+	ReliefThreshold int64
+	ReliefAfter     time.Duration
+	MaxReliefs      int
+	ReliefWindow    time.Duration
+}
+
+// DailyBonus is the bonus of the streak-th consecutive day
+func (c FaucetConfig) DailyBonus(streak int) int64 {
+	if streak > c.MaxStreak {
+		streak = c.MaxStreak
+	}
+	if streak < 1 {
+		streak = 1
+	}
+	return c.Daily + c.StreakBonus*int64(streak-1)
+}
+
+// claimDaily updates the faucet state with a daily claim at now and returns the bonus,
+// the bonus can be claimed once a calendar day
+func (c FaucetConfig) claimDaily(f *model.Faucet, now time.Time) (int64, error) {
+	if c.Daily <= 0 {
+		return 0, ErrFaucetDisabled
+	}
+	if f.Blocked {
+		return 0, ErrFaucetBlocked
+	}
+	today := startOfDay(now)
+	last := startOfDay(f.LastDaily)
+	switch {
+	case last.Equal(today):
+		return 0, ErrDailyClaimed
+	case last.AddDate(0, 0, 1).Equal(today):
+		f.Streak++
+	default:
+		f.Streak = 1
+	}
+	f.LastDaily = now
+	return c.DailyBonus(f.Streak), nil
+}
+
+// relief updates the faucet state with the balance at now and returns the relief to grant, 0 if the player is not due one.
+// Giving money away restarts the wait, so that players cannot empty their balance into another account to be relieved.
+func (c FaucetConfig) relief(f *model.Faucet, balance int64, now time.Time) int64 {
+	if c.ReliefAmount <= 0 || balance >= c.ReliefThreshold {
+		f.BrokeSince = time.Time{}
+		return 0
+	}
+	if f.BrokeSince.IsZero() {
+		f.BrokeSince = now
+	}
+	if f.LastGaveAt.After(f.BrokeSince) {
+		f.BrokeSince = f.LastGaveAt
+	}
+
+	reliefs := f.Reliefs[:0]
+	for _, t := range f.Reliefs {
+		if now.Sub(t) < c.ReliefWindow {
+			reliefs = append(reliefs, t)
+		}
+	}
+	f.Reliefs = reliefs
+
+	if f.Blocked || now.Sub(f.BrokeSince) < c.ReliefAfter || len(f.Reliefs) >= c.MaxReliefs {
+		return 0
+	}
+	f.Reliefs = append(f.Reliefs, now)
+	f.BrokeSince = time.Time{}
+	return c.ReliefAmount
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
@@ -0,0 +1,132 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+var testFaucet = FaucetConfig{
+	Daily:           100,
+	StreakBonus:     20,
+	MaxStreak:       3,
+	ReliefAmount:    300,
+	ReliefThreshold: 50,
+	ReliefAfter:     24 * time.Hour,
+	MaxReliefs:      2,
+	ReliefWindow:    7 * 24 * time.Hour,
+}
+
+func TestFaucetConfig_ClaimDaily(t *testing.T) {
+	day := time.Date(2024, 3, 10, 20, 0, 0, 0, time.Local)
+	f := &model.Faucet{}
+
+	tests := []struct {
+		name       string
+		cfg        FaucetConfig
+		now        time.Time
+		blocked    bool
+		want       int64
+		wantStreak int
+		wantErr    error
+	}{
+		{name: "first claim", cfg: testFaucet, now: day, want: 100, wantStreak: 1},
+		{name: "same day", cfg: testFaucet, now: day.Add(3 * time.Hour), wantStreak: 1, wantErr: ErrDailyClaimed},
+		{name: "next day", cfg: testFaucet, now: day.Add(5 * time.Hour), want: 120, wantStreak: 2},
+		{name: "third day", cfg: testFaucet, now: day.AddDate(0, 0, 2), want: 140, wantStreak: 3},
+		{name: "streak bonus capped", cfg: testFaucet, now: day.AddDate(0, 0, 3), want: 140, wantStreak: 4},
+		{name: "blocked", cfg: testFaucet, now: day.AddDate(0, 0, 4), blocked: true, wantStreak: 4, wantErr: ErrFaucetBlocked},
+		{name: "streak broken", cfg: testFaucet, now: day.AddDate(0, 0, 6), want: 100, wantStreak: 1},
+		{name: "disabled", now: day.AddDate(0, 0, 7), wantStreak: 1, wantErr: ErrFaucetDisabled},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f.Blocked = tt.blocked
+			got, err := tt.cfg.claimDaily(f, tt.now)
+			if err != tt.wantErr || got != tt.want || f.Streak != tt.wantStreak {
+				t.Errorf("claimDaily() = %d, %v, streak %d, want %d, %v, streak %d", got, err, f.Streak, tt.want, tt.wantErr, tt.wantStreak)
+			}
+		})
+	}
+}
+
+func TestFaucetConfig_Relief(t *testing.T) {
+	start := time.Date(2024, 3, 10, 20, 0, 0, 0, time.Local)
+	f := &model.Faucet{}
+
+	tests := []struct {
+		name    string
+		balance int64
+		after   time.Duration // since start
+		gave    time.Duration // since start, 0 for no gift
+		want    int64
+	}{
+		{name: "not broke", balance: 50, after: 0},
+		{name: "broke", balance: 10, after: time.Hour},
+		{name: "not for long", balance: 10, after: 20 * time.Hour},
+		{name: "gave money away", balance: 10, after: 25 * time.Hour, gave: 10 * time.Hour},
+		{name: "broke long enough", balance: 10, after: 35 * time.Hour, want: 300},
+		{name: "broke again", balance: 10, after: 36 * time.Hour},
+		{name: "second relief", balance: 0, after: 60 * time.Hour, want: 300},
+		{name: "broke after the second relief", balance: 0, after: 100 * time.Hour},
+		{name: "recovered", balance: 500, after: 110 * time.Hour},
+		{name: "broke after recovering", balance: 0, after: 120 * time.Hour},
+		{name: "limit still reached", balance: 0, after: 150 * time.Hour},
+		{name: "first relief out of the window", balance: 0, after: 36*time.Hour + 7*24*time.Hour, want: 300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.gave > 0 {
+				f.LastGaveAt = start.Add(tt.gave)
+			}
+			if got := testFaucet.relief(f, tt.balance, start.Add(tt.after)); got != tt.want {
+				t.Errorf("relief() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManager_Faucet(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	p1 := &model.Player{ID: "1", Name: "Player #1"}
+
+	if _, err := m.ClaimDaily(ctx, p1); err != ErrFaucetDisabled {
+		t.Errorf("ClaimDaily() error = %v, want %v", err, ErrFaucetDisabled)
+	}
+	m.SetFaucet(testFaucet)
+	res, err := m.ClaimDaily(ctx, p1)
+	if err != nil {
+		t.Fatalf("ClaimDaily() error = %v", err)
+	}
+	for _, s := range []string{"Bạn nhận được 100☘️, chuỗi 1 ngày", "Số dư: 1.100☘️", "ngày mai để nhận 120☘️"} {
+		if !strings.Contains(res, s) {
+			t.Errorf("ClaimDaily() = %q, want to contain %q", res, s)
+		}
+	}
+	if _, err := m.ClaimDaily(ctx, p1); err != ErrDailyClaimed {
+		t.Errorf("ClaimDaily() error = %v, want %v", err, ErrDailyClaimed)
+	}
+
+	// player 2 goes broke, player 3 is blocked
+	store.balances["2"] = -990
+	store.balances["3"] = -1000
+	if _, err := m.BlockFaucet(ctx, "3", true); err != nil {
+		t.Fatalf("BlockFaucet() error = %v", err)
+	}
+	now := time.Now()
+	if got := m.GrantReliefs(ctx, now); len(got) != 0 {
+		t.Errorf("GrantReliefs() = %v, want none yet", got)
+	}
+	got := m.GrantReliefs(ctx, now.Add(25*time.Hour))
+	if len(got) != 1 || got[0].ID != "2" || got[0].Balance != 310 {
+		t.Errorf("GrantReliefs() = %v, want player 2 with 310", got)
+	}
+	if store.faucets["1"].BrokeSince != (time.Time{}) {
+		t.Errorf("BrokeSince of player 1 = %v, want zero", store.faucets["1"].BrokeSince)
+	}
+}
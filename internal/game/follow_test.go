@@ -0,0 +1,63 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_Follow(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newFakeStore(), 100, 0, time.Minute, DefaultShoeConfig)
+	p1 := &model.Player{ID: "1", Name: "Player #1"}
+	p2 := &model.Player{ID: "2", Name: "Player #2"}
+	p3 := &model.Player{ID: "3", Name: "Player #3"}
+
+	tests := []struct {
+		name    string
+		do      func() error
+		wantErr error
+	}{
+		{name: "follow", do: func() error { return m.Follow(ctx, p2, p1) }},
+		{name: "follow twice", do: func() error { return m.Follow(ctx, p2, p1) }, wantErr: ErrAlreadyFollowing},
+		{name: "follow yourself", do: func() error { return m.Follow(ctx, p2, p2) }, wantErr: ErrPlayYourself},
+		{name: "another follower", do: func() error { return m.Follow(ctx, p3, p1) }},
+		{name: "follow back", do: func() error { return m.Follow(ctx, p1, p2) }},
+		{name: "unfollow", do: func() error { return m.Unfollow(ctx, p3, p1) }},
+		{name: "unfollow twice", do: func() error { return m.Unfollow(ctx, p3, p1) }, wantErr: ErrNotFollowing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.do(); err != tt.wantErr {
+				t.Errorf("error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if got := m.Followers(ctx, p1.ID); len(got) != 1 || got[0].ID != p2.ID {
+		t.Errorf("Followers() = %v, want [%s]", got, p2.ID)
+	}
+
+	// the followee is dealing
+	if _, err := m.CreateRoom(ctx, p1); err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+	if _, err := m.NewGame(p1, ""); err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	res, err := m.Following(ctx, p2)
+	if err != nil {
+		t.Fatalf("Following() error = %v", err)
+	}
+	for _, s := range []string{"Bạn đang theo dõi 1 người", "`Player #1` (đang làm cái", "Có 1 người theo dõi bạn"} {
+		if !strings.Contains(res, s) {
+			t.Errorf("Following() = %q, want to contain %q", res, s)
+		}
+	}
+	if res, _ := m.Following(ctx, p3); !strings.Contains(res, "Bạn chưa theo dõi ai") {
+		t.Errorf("Following() = %q, want no followees", res)
+	}
+}
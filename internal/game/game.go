@@ -2,9 +2,9 @@ package game
 
 import (
 	"bytes"
-	"crypto/rand"
+	"encoding/hex"
 	"fmt"
-	"math/big"
+	"strings"
 	"sync"
 	"time"
 
@@ -17,18 +17,35 @@ import (
 
 type Game struct {
 	id         string
+	roomID     string
+	createdAt  time.Time
 	dealer     *PlayerInGame
 	rule       *Rule
 	players    []*PlayerInGame
-	table      []Card
+	shoe       *Shoe
+	seed       []byte // seed of the shoe when the game was dealt
+	shoeOffset int    // index of the first card dealt in the shoe
+
+	// provably fair, only for games with their own shoe
+	serverSeed  []byte
+	clientSeeds []model.ClientSeed
+
 	status     atomic.Uint32
 	doneCnt    atomic.Uint32
 	maxBet     atomic.Uint64
 	timeout    atomic.Duration
 	currentIdx int
+	dealAt     time.Time // end of the betting window, zero if the dealer deals by hand
 
 	onPlayerPlayFunc func(pg *PlayerInGame)
 
+	events   []model.GameEvent // not saved yet
+	eventSeq int
+	eventsMu sync.Mutex
+
+	finishMu sync.Mutex // held while participants are revealed or the game is finished
+	finished bool       // the game was settled, guarded by finishMu
+
 	mu sync.RWMutex
 }
 
@@ -59,15 +76,28 @@ func (r Result) String() string {
 	}
 }
 
-func NewGame(dealer *model.Player, rule *Rule, maxBet uint64, timeout time.Duration) *Game {
-	return &Game{
+func NewGame(roomID string, dealer *model.Player, rule *Rule, shoe *Shoe, maxBet uint64, timeout time.Duration) *Game {
+	if shoe == nil {
+		shoe = newShoe(DefaultShoeConfig)
+	}
+	var serverSeed []byte
+	if !shoe.shared {
+		serverSeed = shoe.shuffler.NewSeed()
+	}
+	g := &Game{
 		id:         xid.New().String(),
-		dealer:     NewPlayerInGame(dealer, 0, true),
+		roomID:     roomID,
+		createdAt:  time.Now(),
+		dealer:     NewPlayerInGame(dealer, rule, 0, true),
 		rule:       rule,
+		shoe:       shoe,
+		serverSeed: serverSeed,
 		currentIdx: -1,
 		maxBet:     *atomic.NewUint64(maxBet),
 		timeout:    *atomic.NewDuration(timeout),
 	}
+	g.record(model.GameEvent{Type: model.EventNew, PlayerID: dealer.ID, RuleID: rule.orDefault().ID})
+	return g
 }
 
 func (g *Game) ID() string {
@@ -76,6 +106,134 @@ func (g *Game) ID() string {
 	return g.id
 }
 
+// Seed returns the hex seed of the shoe the game was dealt from
+func (g *Game) Seed() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return hex.EncodeToString(g.seed)
+}
+
+// ShoeOffset returns the index of the first card dealt in the shoe
+func (g *Game) ShoeOffset() int {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.shoeOffset
+}
+
+// ReplayCards returns the cards in the order they were drawn in this game
+func (g *Game) ReplayCards() Cards {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.seed) == 0 {
+		return nil
+	}
+	return ReplayCards(g.shoe.Decks(), g.seed, g.shoeOffset)
+}
+
+// IsFair tells if the shuffle of the game can be verified, games dealt from a shoe kept by the room are not
+func (g *Game) IsFair() bool {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return len(g.serverSeed) > 0
+}
+
+// Commitment is the published hash of the server seed
+func (g *Game) Commitment() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.serverSeed) == 0 {
+		return ""
+	}
+	return Commitment(g.serverSeed)
+}
+
+// AddClientSeed adds or replaces the seed contributed by the player, only while betting
+func (g *Game) AddClientSeed(p *model.Player, seed string) error {
+	if len(seed) > MaxClientSeedLength {
+		return ErrSeedTooLong
+	}
+	// "|" separates the seeds in DeriveSeed, so two seed lists could not be told apart
+	if len(seed) == 0 || strings.ContainsAny(seed, "`*_[|") {
+		return ErrInvalidSeed
+	}
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if Status(g.status.Load()) != Betting {
+		return ErrGameAlreadyStarted
+	}
+	if len(g.serverSeed) == 0 {
+		return ErrCannotAddSeed
+	}
+	for i := range g.clientSeeds {
+		if g.clientSeeds[i].PlayerID == p.ID {
+			g.clientSeeds[i].Seed = seed
+			return nil
+		}
+	}
+	g.clientSeeds = append(g.clientSeeds, model.ClientSeed{PlayerID: p.ID, Seed: seed})
+	return nil
+}
+
+// Proof returns the data to verify the shuffle, it reveals the server seed so
+// it must only be published after the game ends
+func (g *Game) Proof() *model.GameProof {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	if len(g.serverSeed) == 0 {
+		return nil
+	}
+	return &model.GameProof{
+		GameID:      g.id,
+		Commitment:  Commitment(g.serverSeed),
+		ServerSeed:  g.serverSeed,
+		ClientSeeds: append([]model.ClientSeed(nil), g.clientSeeds...),
+		Decks:       g.shoe.Decks(),
+		CreatedAt:   time.Now(),
+	}
+}
+
+func (g *Game) CreatedAt() time.Time {
+	return g.createdAt
+}
+
+// Record summarizes the game when it ends
+func (g *Game) Record(status model.GameStatus, endedAt time.Time) *model.GameRecord {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	r := &model.GameRecord{
+		ID:        g.id,
+		RoomID:    g.roomID,
+		RuleID:    g.rule.orDefault().ID,
+		DealerID:  g.dealer.ID,
+		Status:    status,
+		StartedAt: g.createdAt,
+		EndedAt:   endedAt,
+	}
+	for _, pg := range append([]*PlayerInGame{g.dealer}, g.players...) {
+		rp := model.GameRecordPlayer{
+			PlayerID: pg.ID,
+			IsDealer: pg.IsDealer(),
+			Bet:      pg.BetAmount(),
+			Reward:   pg.Reward(),
+		}
+		if len(pg.Cards()) > 0 {
+			rp.ResultType = pg.ResultType()
+			rp.Value = pg.Value()
+		}
+		r.PlayerIDs = append(r.PlayerIDs, pg.ID)
+		r.Participants = append(r.Participants, rp)
+	}
+	return r
+}
+
+func (g *Game) RoomID() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.roomID
+}
+
 func (g *Game) Dealer() *PlayerInGame {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -83,6 +241,11 @@ func (g *Game) Dealer() *PlayerInGame {
 }
 
 func (g *Game) Deal() error {
+	return g.deal(nil)
+}
+
+// deal calls escrow with the bets of the participants before dealing, the game is not dealt if it fails
+func (g *Game) deal(escrow func(bets map[string]uint64) error) error {
 	g.mu.Lock()
 	if Status(g.status.Load()) != Betting {
 		g.mu.Unlock()
@@ -93,34 +256,50 @@ func (g *Game) Deal() error {
 		g.mu.Unlock()
 		return ErrEmptyGame
 	}
-
-	g.table = make(Cards, 52)
-	for i := 0; i < 52; i++ {
-		g.table[i] = Card{id: i}
+	if g.dealer.Balance < g.exposure("") {
+		g.mu.Unlock()
+		return ErrDealerBankFull
 	}
 
-	// shuffle cards
-	for i := 52 - 1; i > 0; i-- {
-		bj, _ := rand.Int(rand.Reader, big.NewInt(int64(i)))
-		j := bj.Int64()
-		g.table[i], g.table[j] = g.table[j], g.table[i]
+	// every hand can take up to "ngũ linh" cards
+	need := (len(g.players) + 1) * g.rule.orDefault().HighFiveCards
+	var err error
+	if len(g.serverSeed) > 0 {
+		err = g.shoe.Reset(need, DeriveSeed(g.serverSeed, g.id, g.clientSeeds))
+	} else {
+		err = g.shoe.Prepare(need)
+	}
+	if err != nil {
+		g.mu.Unlock()
+		return err
 	}
+	if escrow != nil {
+		bets := make(map[string]uint64, len(g.players))
+		for _, pg := range g.players {
+			bets[pg.ID] += pg.BetAmount()
+		}
+		if err := escrow(bets); err != nil {
+			g.mu.Unlock()
+			return err
+		}
+	}
+
+	g.seed, g.shoeOffset = g.shoe.Position()
 
-	// split cards
-	g.dealer.AddCard(g.table[0])
-	g.dealer.AddCard(g.table[len(g.players)+1])
-	// g.dealer.AddCard(Card{id: 0})
-	// g.dealer.AddCard(Card{id: 10})
-	for i := 0; i < len(g.players); i++ {
-		// if i == 2 {
-		//   g.players[i].AddCard(Card{id: 13})
-		//   g.players[i].AddCard(Card{id: 26})
-		// } else {
-		g.players[i].AddCard(g.table[i+1])
-		g.players[i].AddCard(g.table[i+len(g.players)+2])
-		// }
+	// split cards, one by one, dealer first
+	for round := 0; round < 2; round++ {
+		for _, pg := range append([]*PlayerInGame{g.dealer}, g.players...) {
+			c, err := g.shoe.Draw()
+			if err != nil {
+				g.mu.Unlock()
+				return err
+			}
+			pg.AddCard(c)
+		}
+	}
+	for _, pg := range append([]*PlayerInGame{g.dealer}, g.players...) {
+		g.record(model.GameEvent{Type: model.EventDeal, PlayerID: pg.ID, Cards: cardIDs(pg.Cards())})
 	}
-	g.table = g.table[len(g.players)*2+2:]
 	g.doneCnt.Store(uint32(len(g.players)))
 	g.status.Store(uint32(Playing))
 
@@ -141,13 +320,28 @@ func (g *Game) PlayerBet(p *model.Player, betAmount uint64) (*PlayerInGame, erro
 	}
 
 	g.mu.Lock()
+	// bets over what the dealer's bank can cover are trimmed
+	capacity := g.capacity(p.ID)
+	if capacity == 0 {
+		g.mu.Unlock()
+		return nil, ErrDealerBankFull
+	}
+	if betAmount > capacity {
+		betAmount = capacity
+	}
+
 	pg := g.findPlayer(p.ID)
 	if pg == nil {
-		pg = NewPlayerInGame(p, int64(betAmount), false)
+		if len(g.players) >= g.shoe.MaxPlayers(g.rule) {
+			g.mu.Unlock()
+			return nil, ErrTooManyPlayers
+		}
+		pg = NewPlayerInGame(p, g.rule, int64(betAmount), false)
 		g.players = append(g.players, pg)
 	} else {
 		pg.SetBet(betAmount)
 	}
+	g.record(model.GameEvent{Type: model.EventBet, PlayerID: p.ID, Amount: int64(betAmount)})
 	g.mu.Unlock()
 
 	return pg, nil
@@ -159,6 +353,7 @@ func (g *Game) PreparingBoard() string {
 
 	bf := bytes.NewBuffer(nil)
 	bf.WriteString(fmt.Sprintf("Nhà cái: `%s`\n", g.dealer.Name))
+	bf.WriteString(g.ruleLine())
 	bf.WriteString(fmt.Sprintf("Người chơi (%d - %s):", len(g.players), stringer.FormatCurrency(g.totalBetAmount())))
 	if len(g.players) == 0 {
 		bf.WriteString("\n(chưa có ai)")
@@ -167,9 +362,51 @@ func (g *Game) PreparingBoard() string {
 			bf.WriteString(fmt.Sprintf("\n  - `%s`: %s", p.Name, stringer.FormatCurrency(p.BetAmount())))
 		}
 	}
+	bf.WriteString(fmt.Sprintf("\nNhà cái còn nhận cược: %s", stringer.FormatCurrency(g.capacity(""))))
+	if c := g.countdown(); c != "" {
+		bf.WriteString(fmt.Sprintf("\n⏳ Tự động chia bài sau %s", c))
+	}
+	if len(g.serverSeed) > 0 {
+		bf.WriteString(fmt.Sprintf("\n\nMã cam kết: `%s`\nGóp seed: `/seed <chuỗi bất kỳ>` (%d seed)", Commitment(g.serverSeed), len(g.clientSeeds)))
+	}
 	return bf.String()
 }
 
+// Countdown is the time left to bet as shown on the board, empty if the game is not waiting for bets
+func (g *Game) Countdown() string {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.countdown()
+}
+
+// countdown shows the time left in whole minutes, so the board changes at most once a minute
+func (g *Game) countdown() string {
+	if g.dealAt.IsZero() || Status(g.status.Load()) != Betting {
+		return ""
+	}
+	left := time.Until(g.dealAt)
+	if left <= 0 {
+		return ""
+	}
+	if left <= time.Minute {
+		return "chưa tới 1 phút"
+	}
+	return fmt.Sprintf("%d phút", (left+time.Minute-1)/time.Minute)
+}
+
+// DealAt is the end of the betting window, zero if there is none
+func (g *Game) DealAt() time.Time {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.dealAt
+}
+
+func (g *Game) setDealAt(t time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.dealAt = t
+}
+
 func (g *Game) CurrentBoard() string {
 	g.mu.RLock()
 	defer g.mu.RUnlock()
@@ -202,10 +439,11 @@ func (g *Game) ResultBoard() string {
 	defer g.mu.RUnlock()
 
 	bf := bytes.NewBuffer(nil)
-	bf.WriteString(fmt.Sprintf("Nhà cái: %s\n", g.dealer.Cards().String(false, true)))
+	bf.WriteString(g.ruleLine())
+	bf.WriteString(fmt.Sprintf("Nhà cái: %s\n", g.dealer.CardsInfo()))
 	bf.WriteString(fmt.Sprintf("Người chơi (%d - %s):", len(g.players), stringer.FormatCurrency(g.totalBetAmount())))
 	for _, p := range g.players {
-		bf.WriteString(fmt.Sprintf("\n  - `%s`: %s", p.Name, p.Cards().String(false, false)))
+		bf.WriteString(fmt.Sprintf("\n  - `%s`: %s", p.Name, p.CardsInfo()))
 	}
 
 	bf.WriteString(fmt.Sprintf("\n\nThưởng:\n\nNhà cái (`%s`): %s (%s)\n",
@@ -217,6 +455,10 @@ func (g *Game) ResultBoard() string {
 	for _, p := range g.players {
 		bf.WriteString(fmt.Sprintf("\n  - `%s`: %s (%s)", p.Name, stringer.FormatCurrency(p.Reward()), stringer.FormatCurrency(p.Balance+p.Reward())))
 	}
+	bf.WriteString(fmt.Sprintf("\n\nXem lại: /replay %s", g.id))
+	if len(g.serverSeed) > 0 {
+		bf.WriteString(fmt.Sprintf("\nSeed: `%s`\nXác minh: /verify %s", hex.EncodeToString(g.serverSeed), g.id))
+	}
 	return bf.String()
 }
 
@@ -226,6 +468,8 @@ type ResultMapItem struct {
 	ResultType model.ResultType
 	Value      int
 	IsDealer   bool
+	Bet        uint64 // total bets of the participants for the dealer
+	StartValue int
 }
 
 func (g *Game) ResultMap() []ResultMapItem {
@@ -233,21 +477,27 @@ func (g *Game) ResultMap() []ResultMapItem {
 	defer g.mu.RUnlock()
 
 	result := make([]ResultMapItem, len(g.players)+1)
+	total := uint64(0)
 	for i, p := range g.players {
 		result[i] = ResultMapItem{
 			PlayerID:   p.ID,
 			Reward:     p.Reward(),
 			ResultType: p.ResultType(),
-			Value:      p.Cards().Value(),
+			Value:      p.Value(),
 			IsDealer:   false,
+			Bet:        p.BetAmount(),
+			StartValue: p.StartValue(),
 		}
+		total += p.BetAmount()
 	}
 	result[len(g.players)] = ResultMapItem{
 		PlayerID:   g.dealer.ID,
 		Reward:     g.dealer.Reward(),
 		ResultType: g.dealer.ResultType(),
-		Value:      g.dealer.Cards().Value(),
+		Value:      g.dealer.Value(),
 		IsDealer:   true,
+		Bet:        total,
+		StartValue: g.dealer.StartValue(),
 	}
 	return result
 }
@@ -270,6 +520,7 @@ func (g *Game) RemovePlayer(id string) error {
 			continue
 		}
 		g.players = append(g.players[:i], g.players[i+1:]...)
+		g.record(model.GameEvent{Type: model.EventLeave, PlayerID: id})
 		return nil
 	}
 	return ErrPlayerNotFound
@@ -296,9 +547,17 @@ func (g *Game) PlayersInGame() []*PlayerInGame {
 func (g *Game) RemoveCard() (Card, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	// TODO: check if available
-	c := g.table[0]
-	g.table = g.table[1:]
+	return g.shoe.Draw()
+}
+
+// Hit draws a card for the player
+func (g *Game) Hit(pg *PlayerInGame) (Card, error) {
+	c, err := g.RemoveCard()
+	if err != nil {
+		return c, err
+	}
+	pg.AddCard(c)
+	g.record(model.GameEvent{Type: model.EventHit, PlayerID: pg.ID, Cards: []int{c.id}})
 	return c, nil
 }
 
@@ -320,6 +579,9 @@ func (g *Game) PlayerStand(pg *PlayerInGame) (err error) {
 		}
 		err = g.players[g.currentIdx].Stand()
 	}
+	if err == nil {
+		g.record(model.GameEvent{Type: model.EventStand, PlayerID: pg.ID})
+	}
 	return err
 }
 
@@ -357,7 +619,32 @@ func (g *Game) PlayerNext() (*PlayerInGame, error) {
 	return playPG, nil
 }
 
+// Done reveals the participant and settles them against the dealer, the rule's reveal limits
+// apply unless force is set
 func (g *Game) Done(pg *PlayerInGame, force bool) (int64, error) {
+	if !force && !pg.IsDone() && !g.rule.orDefault().CanReveal(g.dealer, pg) {
+		return 0, ErrRevealNotAllowed
+	}
+	return g.settle(pg, force)
+}
+
+// CanReveal checks if the dealer may reveal the participant now
+func (g *Game) CanReveal(pg *PlayerInGame) bool {
+	return !pg.IsDone() && g.rule.orDefault().CanReveal(g.dealer, pg)
+}
+
+// CanFinish checks if the dealer may end the game, the rule must let them reveal every participant left
+func (g *Game) CanFinish() bool {
+	for _, pg := range g.PlayersInGame() {
+		if !pg.IsDone() && !g.CanReveal(pg) {
+			return false
+		}
+	}
+	return true
+}
+
+// settle compares the participant against the dealer, it is how the game ends so reveal limits do not apply
+func (g *Game) settle(pg *PlayerInGame, force bool) (int64, error) {
 	if pg.IsDone() {
 		if pg.IsDealer() {
 			return pg.Reward(), nil
@@ -380,6 +667,7 @@ func (g *Game) Done(pg *PlayerInGame, force bool) (int64, error) {
 	g.dealer.AddReward(reward)
 	g.doneCnt.Dec()
 	pg.Done(-reward)
+	g.record(model.GameEvent{Type: model.EventCompare, PlayerID: pg.ID, Amount: reward})
 	if g.doneCnt.Load() == 0 {
 		g.status.Store(uint32(Finished))
 	}
@@ -425,14 +713,26 @@ func (g *Game) CurrentPlaying() *PlayerInGame {
 
 func (g *Game) Pass(pg *PlayerInGame) error {
 	passed := time.Duration(time.Now().Unix()-pg.LastHit()) * time.Second
-	need := g.timeout.Load()
+	if passed < g.TurnTimeout(pg) {
+		return ErrNotTimeout
+	}
+	return g.pass(pg)
+}
+
+// TurnTimeout is how long the player may stay idle in their turn, the dealer gets 5 times longer
+func (g *Game) TurnTimeout(pg *PlayerInGame) time.Duration {
 	if pg.IsDealer() {
-		need = need * 5
+		return g.timeout.Load() * 5
 	}
-	if passed < need {
-		return ErrNotTimeout
+	return g.timeout.Load()
+}
+
+// pass ends the turn of the playing player without standing, it ends the game if the player is the dealer
+func (g *Game) pass(pg *PlayerInGame) error {
+	if !pg.status.CAS(uint32(PlayerPlaying), uint32(PlayerStood)) {
+		return ErrYouNotPlaying
 	}
-	pg.SetStatus(PlayerStood)
+	g.record(model.GameEvent{Type: model.EventPass, PlayerID: pg.ID})
 	if pg.IsDealer() {
 		g.status.Store(uint32(Finished))
 		return nil
@@ -457,6 +757,72 @@ func (g *Game) Players() []model.Player {
 	return res
 }
 
+func (g *Game) record(ev model.GameEvent) {
+	g.eventsMu.Lock()
+	defer g.eventsMu.Unlock()
+	g.eventSeq++
+	ev.GameID = g.id
+	ev.Seq = g.eventSeq
+	ev.CreatedAt = time.Now()
+	g.events = append(g.events, ev)
+}
+
+func (g *Game) lastEventSeq() int {
+	g.eventsMu.Lock()
+	defer g.eventsMu.Unlock()
+	return g.eventSeq
+}
+
+// takeEvents returns the events which are not saved yet
+func (g *Game) takeEvents() []model.GameEvent {
+	g.eventsMu.Lock()
+	defer g.eventsMu.Unlock()
+	evs := g.events
+	g.events = nil
+	return evs
+}
+
+func (g *Game) ruleLine() string {
+	return fmt.Sprintf("Luật: `%s` (%s)\n", g.rule.Name, g.rule.Payouts())
+}
+
+// Capacity is the largest bet the dealer can still cover
+func (g *Game) Capacity() uint64 {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+	return g.capacity("")
+}
+
+// capacity is the largest bet the dealer's bank can still cover if every participant wins
+// with the highest multiplier of the rule, not counting the current bet of the player except
+func (g *Game) capacity(except string) uint64 {
+	exposure := g.exposure(except)
+	if g.dealer.Balance <= exposure {
+		return 0
+	}
+	return uint64((g.dealer.Balance - exposure) / g.rule.orDefault().MaxMultiplier())
+}
+
+// exposure is what the dealer pays if every participant but except wins with the highest multiplier of the rule
+func (g *Game) exposure(except string) int64 {
+	mul := g.rule.orDefault().MaxMultiplier()
+	res := int64(0)
+	for _, p := range g.players {
+		if p.ID != except {
+			res += int64(p.BetAmount()) * mul
+		}
+	}
+	return res
+}
+
+// setDealerBalance updates the bank the bets are checked against, the dealer may have given or lent money
+// away since the game was created
+func (g *Game) setDealerBalance(balance int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.dealer.Balance = balance
+}
+
 func (g *Game) totalBetAmount() uint64 {
 	res := uint64(0)
 	for _, p := range g.players {
@@ -465,9 +831,9 @@ func (g *Game) totalBetAmount() uint64 {
 	return res
 }
 
-func Compare(a, b *PlayerInGame) Result {
-	rta := a.Cards().Type(a.IsDealer())
-	rtb := b.Cards().Type(b.IsDealer())
+func Compare(rule *Rule, a, b *PlayerInGame) Result {
+	rta := a.Cards().Type(rule, a.IsDealer())
+	rtb := b.Cards().Type(rule, b.IsDealer())
 	if rta < rtb {
 		return Win
 	} else if rta > rtb {
@@ -476,7 +842,7 @@ func Compare(a, b *PlayerInGame) Result {
 	if rta == model.TypeTooHigh || rta == model.TypeBusted || rta == model.TypeTooLow {
 		return Draw
 	}
-	res := compareScore(a.Cards().Value(), b.Cards().Value())
+	res := compareScore(a.Cards().Value(rule), b.Cards().Value(rule))
 	if rta == model.TypeHighFive {
 		res = reverseResult(res)
 	}
@@ -484,12 +850,12 @@ func Compare(a, b *PlayerInGame) Result {
 }
 
 func GetReward(rule *Rule, dealer, participant *PlayerInGame) int64 {
-	cp := Compare(dealer, participant)
+	cp := Compare(rule, dealer, participant)
 	if cp == Draw {
 		return 0
 	}
-	rtDealer := dealer.Cards().Type(true)
-	rtb := participant.Cards().Type(false)
+	rtDealer := dealer.Cards().Type(rule, true)
+	rtb := participant.Cards().Type(rule, false)
 
 	bm := int64(participant.BetAmount())
 	var coff int64
@@ -1,9 +1,11 @@
 package game
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"testing"
+	"time"
 
 	"go.uber.org/atomic"
 
@@ -31,7 +33,7 @@ func TestCompare(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			pa := &PlayerInGame{cards: NewCards(tt.args.aIds...), isDealer: *atomic.NewBool(true)}
 			pb := &PlayerInGame{cards: NewCards(tt.args.bIds...), isDealer: *atomic.NewBool(false)}
-			if got := Compare(pa, pb); got != tt.want {
+			if got := Compare(&DefaultRule, pa, pb); got != tt.want {
 				t.Errorf("Compare() = %v, want %v", got, tt.want)
 			}
 		})
@@ -74,12 +76,12 @@ func TestGetReward(t *testing.T) {
 		{args: args{aIds: []int{1, 2}, bIds: []int{0, 5, 9}}, want: -1},
 		{args: args{aIds: []int{9, 5}, bIds: []int{0, 5, 7}}, want: 1},
 		{args: args{aIds: []int{7, 8}, bIds: []int{0, 5, 7}}, want: 1},
-		{args: args{aIds: []int{7, 8}, bIds: []int{0, 13}}, want: -2},
-		{args: args{aIds: []int{7, 8}, bIds: []int{0, 13}, ruleID: "2"}, want: -3},
+		{args: args{aIds: []int{7, 8}, bIds: []int{0, 13}, ruleID: "2"}, want: -2},
+		{args: args{aIds: []int{7, 8}, bIds: []int{0, 13}}, want: -3},
 		{args: args{aIds: []int{0, 13}, bIds: []int{26, 39}}, want: 0},
-		{args: args{aIds: []int{0, 13}, bIds: []int{1, 2}}, want: 1},
-		{args: args{aIds: []int{0, 13}, bIds: []int{1, 2}, ruleID: "2"}, want: 3},
-		{args: args{aIds: []int{0, 10}, bIds: []int{1, 2}, ruleID: "2"}, want: 2},
+		{args: args{aIds: []int{0, 13}, bIds: []int{1, 2}, ruleID: "2"}, want: 1},
+		{args: args{aIds: []int{0, 13}, bIds: []int{1, 2}}, want: 3},
+		{args: args{aIds: []int{0, 10}, bIds: []int{1, 2}}, want: 2},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -98,11 +100,12 @@ func TestGetReward(t *testing.T) {
 
 func TestGame_Deal(t *testing.T) {
 	g := &Game{
-		dealer: NewPlayerInGame(&model.Player{}, 0, true),
+		dealer: NewPlayerInGame(&model.Player{}, nil, 0, true),
 		players: []*PlayerInGame{
-			NewPlayerInGame(&model.Player{Name: "Bot #1"}, 10, false),
-			NewPlayerInGame(&model.Player{Name: "Bot #2"}, 20, false),
+			NewPlayerInGame(&model.Player{Name: "Bot #1"}, nil, 10, false),
+			NewPlayerInGame(&model.Player{Name: "Bot #2"}, nil, 20, false),
 		},
+		shoe: NewShoe(1, DefaultPenetration, NewSeededShuffler(1)),
 	}
 	wg := &sync.WaitGroup{}
 	for i := 0; i < 10; i++ {
@@ -118,3 +121,63 @@ func TestGame_Deal(t *testing.T) {
 
 	fmt.Println(g.CurrentBoard())
 }
+
+func TestGame_PlayerBet_Capacity(t *testing.T) {
+	dealer := &model.Player{ID: "1", Balance: 1000}
+	g := NewGame("r", dealer, &DefaultRule, NewShoe(1, DefaultPenetration, NewSeededShuffler(1)), 1000, time.Minute)
+	if got, want := g.Capacity(), uint64(333); got != want {
+		t.Fatalf("Capacity() = %d, want %d", got, want)
+	}
+
+	// the rule pays participants up to x3
+	tests := []struct {
+		name     string
+		playerID string
+		amount   uint64
+		want     uint64
+		wantErr  error
+	}{
+		{name: "covered", playerID: "2", amount: 200, want: 200},
+		{name: "trimmed", playerID: "3", amount: 300, want: 133},
+		{name: "bank full", playerID: "4", amount: 10, wantErr: ErrDealerBankFull},
+		{name: "own bet is not counted", playerID: "2", amount: 250, want: 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pg, err := g.PlayerBet(&model.Player{ID: tt.playerID, Balance: 1000}, tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("PlayerBet() error = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && pg.BetAmount() != tt.want {
+				t.Errorf("PlayerBet() bet = %d, want %d", pg.BetAmount(), tt.want)
+			}
+		})
+	}
+}
+
+func TestGame_Countdown(t *testing.T) {
+	g := NewGame("r", &model.Player{ID: "1", Balance: 1000}, &DefaultRule, NewShoe(1, DefaultPenetration, NewSeededShuffler(1)), 1000, time.Minute)
+	tests := []struct {
+		name string
+		left time.Duration
+		want string
+	}{
+		{name: "no window", want: ""},
+		{name: "minutes rounded up", left: 2*time.Minute + 10*time.Second, want: "3 phút"},
+		{name: "whole minutes", left: 2*time.Minute - 5*time.Second, want: "2 phút"},
+		{name: "last minute", left: 40 * time.Second, want: "chưa tới 1 phút"},
+		{name: "over", left: -time.Second, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			at := time.Time{}
+			if tt.left != 0 {
+				at = time.Now().Add(tt.left)
+			}
+			g.setDealAt(at)
+			if got := g.Countdown(); got != tt.want {
+				t.Errorf("Countdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_PlayerHistory(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Shuffler: NewSeededShuffler(1)})
+	g := playGame(t, m)
+
+	// older games without events
+	now := time.Now()
+	for i := 0; i < 2*HistoryPageSize; i++ {
+		_ = store.SaveRecord(ctx, &model.Record{GameID: "old", PlayerID: "2", Reward: 10, CreatedAt: now.Add(time.Duration(i) * time.Minute)})
+	}
+	// the played game is the newest
+	for i := range store.results {
+		if store.results[i].GameID == g.ID() {
+			r := store.results[i]
+			r.CreatedAt = now.Add(time.Hour)
+			_ = store.SaveRecord(ctx, &r)
+		}
+	}
+
+	p := &model.Player{ID: "2"}
+	tests := []struct {
+		name     string
+		cursor   func(prev *HistoryPage) model.Cursor
+		games    int
+		hasPrev  bool
+		hasNext  bool
+		contains string
+	}{
+		{name: "first", cursor: func(*HistoryPage) model.Cursor { return model.Cursor{} }, games: HistoryPageSize, hasNext: true, contains: "Nhà cái `Player #1`"},
+		{name: "second", cursor: func(prev *HistoryPage) model.Cursor { return *prev.Next }, games: HistoryPageSize, hasPrev: true, hasNext: true},
+		{name: "last", cursor: func(prev *HistoryPage) model.Cursor { return *prev.Next }, games: 2, hasPrev: true},
+		{name: "back", cursor: func(prev *HistoryPage) model.Cursor { return *prev.Prev }, games: HistoryPageSize, hasPrev: true, hasNext: true},
+		{name: "back to first", cursor: func(prev *HistoryPage) model.Cursor { return *prev.Prev }, games: HistoryPageSize, hasNext: true, contains: "Bài bạn:"},
+	}
+	var page *HistoryPage
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.PlayerHistory(ctx, p, time.Time{}, time.Time{}, tt.cursor(page))
+			if err != nil {
+				t.Fatalf("PlayerHistory() error = %v", err)
+			}
+			if len(got.GameIDs) != tt.games || (got.Prev != nil) != tt.hasPrev || (got.Next != nil) != tt.hasNext {
+				t.Errorf("PlayerHistory() games = %d, prev = %v, next = %v, want %d, %v, %v",
+					len(got.GameIDs), got.Prev, got.Next, tt.games, tt.hasPrev, tt.hasNext)
+			}
+			if !strings.Contains(got.Text, tt.contains) {
+				t.Errorf("PlayerHistory() = %q, want to contain %q", got.Text, tt.contains)
+			}
+			page = got
+		})
+	}
+	if page.GameIDs[0] != g.ID() {
+		t.Errorf("PlayerHistory() first game = %s, want %s", page.GameIDs[0], g.ID())
+	}
+
+	// the multiplier is not rounded to a whole number
+	bet := int64(g.FindPlayer(p.ID).BetAmount())
+	for reward, want := range map[int64]string{bet * 3 / 2: ", x1.5:", -bet / 2: ", x0.5:", 2 * bet: ", x2:"} {
+		if got := m.historyEntry(ctx, p, model.Record{GameID: g.ID(), PlayerID: p.ID, Reward: reward}); !strings.Contains(got, want) {
+			t.Errorf("historyEntry() with reward %d = %q, want to contain %q", reward, got, want)
+		}
+	}
+}
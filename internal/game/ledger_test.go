@@ -0,0 +1,87 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_PlayerStatement(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	store.balances["1"] = -30
+	store.ledger = []model.LedgerEntry{
+		{Account: "1", Amount: 1000, Reason: model.LedgerDeposit},
+		{Account: "1", Amount: -50, Reason: model.LedgerBet, Reference: "g1"},
+		{Account: "1", Amount: 20, Reason: model.LedgerPayout, Reference: "g1"},
+	}
+
+	res, err := m.PlayerStatement(ctx, &model.Player{ID: "1"}, 10)
+	if err != nil {
+		t.Fatalf("PlayerStatement() error = %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(res), "\n")
+	if len(lines) != 5 {
+		t.Fatalf("PlayerStatement() = %q, want 3 entries", res)
+	}
+	// newest first, with the balance after each entry
+	wants := []string{"Trả thưởng 20☘️ (`g1`) → 970☘️", "Cược -50☘️ (`g1`) → 950☘️", "Nạp 1.000☘️ → 1.000☘️"}
+	for i, want := range wants {
+		if !strings.HasSuffix(lines[i+2], want) {
+			t.Errorf("entry %d = %q, want suffix %q", i, lines[i+2], want)
+		}
+	}
+}
+
+func TestManager_ReconcileLedger(t *testing.T) {
+	tests := []struct {
+		name     string
+		balances map[string]int64
+		ledger   []model.LedgerEntry
+		want     string
+	}{
+		{
+			name:     "balanced",
+			balances: map[string]int64{"1": -50, "2": 50},
+			ledger: []model.LedgerEntry{
+				{Account: "1", Amount: 1000}, {Account: "2", Amount: 1000}, {Account: model.AccountHouse, Amount: -2000},
+				{Account: "1", Amount: -50}, {Account: model.AccountEscrow, Amount: 50},
+				{Account: model.AccountEscrow, Amount: -50}, {Account: "2", Amount: 50},
+			},
+			want: "Sổ cái khớp",
+		},
+		{
+			name:     "balance changed outside the ledger",
+			balances: map[string]int64{"1": -50, "2": 0},
+			ledger: []model.LedgerEntry{
+				{Account: "1", Amount: 1000}, {Account: "2", Amount: 1000}, {Account: model.AccountHouse, Amount: -2000},
+			},
+			want: "1 lệch",
+		},
+		{
+			name:     "unbalanced entries",
+			balances: map[string]int64{"1": 0},
+			ledger:   []model.LedgerEntry{{Account: "1", Amount: 1000}},
+			want:     "Sổ cái không cân",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore()
+			store.balances = tt.balances
+			store.ledger = tt.ledger
+			m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+			res, err := m.ReconcileLedger(context.Background())
+			if err != nil {
+				t.Fatalf("ReconcileLedger() error = %v", err)
+			}
+			if !strings.Contains(res, tt.want) {
+				t.Errorf("ReconcileLedger() = %q, want %q", res, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_Give(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	p1 := &model.Player{ID: "1", Name: "Player #1"}
+	p2 := &model.Player{ID: "2", Name: "Player #2"}
+
+	tests := []struct {
+		name    string
+		to      *model.Player
+		amount  int64
+		wantErr error
+	}{
+		{name: "give", to: p2, amount: 300},
+		{name: "give yourself", to: p1, amount: 300, wantErr: ErrPlayYourself},
+		{name: "nothing", to: p2, amount: 0, wantErr: ErrInvalidAmount},
+		{name: "more than balance", to: p2, amount: 800, wantErr: model.ErrInsufficientBalance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, _, err := m.Give(ctx, p1, tt.to, tt.amount); err != tt.wantErr {
+				t.Errorf("Give() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+	if store.balances["1"] != -300 || store.balances["2"] != 300 {
+		t.Errorf("balances = %v, want 1: -300, 2: 300", store.balances)
+	}
+}
+
+func TestManager_Loan(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	p1 := &model.Player{ID: "1", Name: "Player #1"}
+	p2 := &model.Player{ID: "2", Name: "Player #2"}
+	p3 := &model.Player{ID: "3", Name: "Player #3"}
+	now := time.Now()
+
+	tests := []struct {
+		name    string
+		lender  *model.Player
+		amount  int64
+		due     time.Time
+		auto    bool
+		wantErr error
+	}{
+		{name: "due and auto repaid", lender: p1, amount: 300, due: now.Add(time.Hour), auto: true},
+		{name: "no terms", lender: p1, amount: 100},
+		{name: "another lender", lender: p3, amount: 50, auto: true},
+		{name: "lend yourself", lender: p2, amount: 100, wantErr: ErrPlayYourself},
+		{name: "due in the past", lender: p1, amount: 100, due: now.Add(-time.Hour), wantErr: ErrInvalidDueDate},
+		{name: "more than balance", lender: p1, amount: 1000, wantErr: model.ErrInsufficientBalance},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := m.Lend(ctx, tt.lender, p2, tt.amount, tt.due, tt.auto); err != tt.wantErr {
+				t.Errorf("Lend() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	res, err := m.Debts(ctx, p2)
+	if err != nil {
+		t.Fatalf("Debts() error = %v", err)
+	}
+	for _, s := range []string{"Bạn đang nợ 450☘️", "`Player #1`: 300☘️, hạn ", ", tự trừ khi thắng", "`Player #1`: 100☘️\n", "`Player #3`: 50☘️"} {
+		if !strings.Contains(res, s) {
+			t.Errorf("Debts() = %q, want to contain %q", res, s)
+		}
+	}
+	if res, _ := m.Debts(ctx, p1); !strings.Contains(res, "Bạn đang cho vay 400☘️") {
+		t.Errorf("Debts() = %q, want lent 400", res)
+	}
+
+	// winnings repay the auto repaid loans oldest first, losses repay nothing
+	repayments, err := store.SettleGame(ctx, "g1", map[string]int64{"1": -320, "2": 320}, nil, nil)
+	if err != nil || len(repayments) != 2 || repayments[0].Amount != 300 || !repayments[0].Loan.Closed || repayments[1].Amount != 20 {
+		t.Errorf("SettleGame() = %+v, %v, want 300 then 20", repayments, err)
+	}
+
+	if _, _, err := m.Repay(ctx, p3, p1, 0); err != ErrNoDebt {
+		t.Errorf("Repay() error = %v, want %v", err, ErrNoDebt)
+	}
+	paid, owed, err := m.Repay(ctx, p2, p3, 10)
+	if err != nil || paid != 10 || owed != 20 {
+		t.Errorf("Repay() = %d, %d, %v, want 10, 20", paid, owed, err)
+	}
+	paid, owed, err = m.Repay(ctx, p2, p1, 0)
+	if err != nil || paid != 100 || owed != 0 {
+		t.Errorf("Repay() = %d, %d, %v, want 100, 0", paid, owed, err)
+	}
+	// the game paid 2 the 320 it repaid
+	if store.balances["1"] != -320 || store.balances["2"] != 340 || store.balances["3"] != -20 {
+		t.Errorf("balances = %v, want 1: -320, 2: 340, 3: -20", store.balances)
+	}
+}
+
+func TestManager_RemindLoans(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newFakeStore(), 100, 0, time.Minute, DefaultShoeConfig)
+	p1 := &model.Player{ID: "1", Name: "Player #1"}
+	p2 := &model.Player{ID: "2", Name: "Player #2"}
+	now := time.Now()
+	if _, err := m.Lend(ctx, p1, p2, 100, now.Add(48*time.Hour), false); err != nil {
+		t.Fatalf("Lend() error = %v", err)
+	}
+	if _, err := m.Lend(ctx, p1, p2, 100, time.Time{}, false); err != nil {
+		t.Fatalf("Lend() error = %v", err)
+	}
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want int
+	}{
+		{name: "not due soon", now: now, want: 0},
+		{name: "due soon", now: now.Add(30 * time.Hour), want: 1},
+		{name: "already reminded", now: now.Add(40 * time.Hour), want: 0},
+		{name: "overdue", now: now.Add(60 * time.Hour), want: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.RemindLoans(ctx, tt.now); len(got) != tt.want {
+				t.Errorf("RemindLoans() = %d loans, want %d", len(got), tt.want)
+			}
+		})
+	}
+}
@@ -4,13 +4,18 @@ import (
 	"bytes"
 	"context"
 	"encoding/gob"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/rs/xid"
 	"github.com/rs/zerolog/log"
 	"go.uber.org/atomic"
 
@@ -29,12 +34,19 @@ type Manager struct {
 	maxBet  atomic.Uint64
 	minDeal atomic.Uint64
 	timeout atomic.Duration
+	window  atomic.Duration // betting window of new rooms
+	shoeCfg ShoeConfig
+	faucet  atomic.Pointer[FaucetConfig]
 
-	players       sync.Map
 	canCreateGame atomic.Bool
-	currentGame   *Game
+	rooms         map[string]*Room
+	players       map[string]*Room // player id -> joined room
 
-	store Storage
+	store     Storage
+	saveMu    sync.Mutex
+	rulesFile string
+	turns     *turnTimers
+	betting   *countdowns
 
 	mu                sync.RWMutex
 	onNewGameFunc     OnNewGameFunc
@@ -45,6 +57,12 @@ type Manager struct {
 	onPlayerHitFunc   OnPlayerHitFunc
 	onGameFinishFunc  OnGameFinishFunc
 	onPlayerPlayFunc  OnPlayerPlayFunc
+	onTurnWarningFunc OnTurnWarningFunc
+	onTurnTimeoutFunc OnTurnTimeoutFunc
+
+	onBettingTickFunc    OnBettingTickFunc
+	onBettingTimeoutFunc OnBettingTimeoutFunc
+	onLoanRepaidFunc     OnLoanRepaidFunc
 }
 
 type OnNewGameFunc func(g *Game)
@@ -56,13 +74,34 @@ type OnPlayerHitFunc func(g *Game, p *PlayerInGame)
 type OnGameFinishFunc func(g *Game)
 type OnPlayerPlayFunc func(g *Game, pg *PlayerInGame)
 
-func NewManager(store Storage, maxBet uint64, minDeal uint64, timeout time.Duration) *Manager {
+// OnTurnWarningFunc is called when the turn of pg ends in left
+type OnTurnWarningFunc func(g *Game, pg *PlayerInGame, left time.Duration)
+
+// OnTurnTimeoutFunc is called when pg timed out, before they stand (stood is true) or are passed.
+// A dealer who timed out reveals all remaining participants.
+type OnTurnTimeoutFunc func(g *Game, pg *PlayerInGame, stood bool)
+
+// OnBettingTickFunc is called while the game waits for bets, left is the time until it is dealt
+type OnBettingTickFunc func(g *Game, left time.Duration)
+
+// OnBettingTimeoutFunc is called when the betting window ended, the game was dealt if anyone bet, cancelled otherwise
+type OnBettingTimeoutFunc func(g *Game, dealt bool)
+
+// OnLoanRepaidFunc is called when a loan is repaid from the winnings of the borrower
+type OnLoanRepaidFunc func(r model.Repayment)
+
+func NewManager(store Storage, maxBet uint64, minDeal uint64, timeout time.Duration, shoeCfg ShoeConfig) *Manager {
 	m := &Manager{
 		maxBet:        *atomic.NewUint64(maxBet),
 		minDeal:       *atomic.NewUint64(minDeal),
 		timeout:       *atomic.NewDuration(timeout),
+		shoeCfg:       shoeCfg,
 		canCreateGame: *atomic.NewBool(true),
+		rooms:         make(map[string]*Room),
+		players:       make(map[string]*Room),
 		store:         store,
+		turns:         newTurnTimers(),
+		betting:       newCountdowns(),
 	}
 	return m
 }
@@ -113,6 +152,33 @@ func (m *Manager) findPlayer(ctx context.Context, id string) *model.Player {
 	return nil
 }
 
+// LookupPlayer finds a player by ID or by name, case-insensitively and with or without the leading @
+func (m *Manager) LookupPlayer(ctx context.Context, query string) (*model.Player, error) {
+	query = strings.TrimSpace(query)
+	if p := m.findPlayer(ctx, query); p != nil {
+		return p, nil
+	}
+	players, err := m.store.ListPlayers(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := strings.TrimPrefix(query, "@")
+	var found *model.Player
+	for i := range players {
+		if !strings.EqualFold(strings.TrimPrefix(players[i].Name, "@"), name) {
+			continue
+		}
+		if found != nil {
+			return nil, ErrPlayerAmbiguous
+		}
+		found = &players[i]
+	}
+	if found == nil {
+		return nil, ErrPlayerNotFound
+	}
+	return found, nil
+}
+
 func (m *Manager) ActivePlayers(ctx context.Context) []model.Player {
 	ps, err := m.store.ListActivePlayers(ctx)
 	if err != nil {
@@ -146,7 +212,7 @@ func (m *Manager) Leave(ctx context.Context, p *model.Player) error {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	if m.currentGame != nil {
+	if r := m.players[p.ID]; r != nil && r.Game() != nil {
 		return ErrYouAlreadyInGame
 	}
 
@@ -185,6 +251,30 @@ func (m *Manager) OnPlayerStand(f OnPlayerStandFunc) {
 	m.onPlayerStandFunc = f
 }
 
+func (m *Manager) OnTurnWarning(f OnTurnWarningFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onTurnWarningFunc = f
+}
+
+func (m *Manager) OnTurnTimeout(f OnTurnTimeoutFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onTurnTimeoutFunc = f
+}
+
+func (m *Manager) OnBettingTick(f OnBettingTickFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onBettingTickFunc = f
+}
+
+func (m *Manager) OnBettingTimeout(f OnBettingTimeoutFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onBettingTimeoutFunc = f
+}
+
 func (m *Manager) OnGameFinish(f OnGameFinishFunc) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -203,40 +293,94 @@ func (m *Manager) OnPlayerPlay(f OnPlayerPlayFunc) {
 	m.onPlayerPlayFunc = f
 }
 
-func (m *Manager) NewGame(dealer *model.Player) (*Game, error) {
+func (m *Manager) OnLoanRepaid(f OnLoanRepaidFunc) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.onLoanRepaidFunc = f
+}
+
+// NewGame creates a game in the dealer's room with the given rule,
+// an empty ruleID falls back to the dealer's preferred rule then the room's rule
+func (m *Manager) NewGame(dealer *model.Player, ruleID string) (*Game, error) {
 	if !m.canCreateGame.Load() {
 		return nil, ErrServerMaintenance
 	}
+	if len(ruleID) == 0 {
+		ruleID = dealer.RuleID
+	}
+	var rule *Rule
+	if len(ruleID) > 0 {
+		r, ok := Rules.Get(ruleID)
+		if !ok {
+			return nil, fmt.Errorf("không tìm thấy rule: %s", ruleID)
+		}
+		rule = &r
+	}
 	if dealer.Balance < int64(m.minDeal.Load()) {
 		return nil, fmt.Errorf("tích thêm đi bạn ơi, tối thiểu %s", stringer.FormatCurrency(m.minDeal.Load()))
 	}
 
 	m.mu.Lock()
-	if m.currentGame != nil {
+	r := m.players[dealer.ID]
+	if r == nil {
+		m.mu.Unlock()
+		return nil, ErrNotInRoom
+	}
+	if r.Game() != nil {
 		m.mu.Unlock()
 		return nil, ErrGameIsExisted
 	}
 
-	g := NewGame(dealer, &DefaultRule, m.maxBet.Load(), m.timeout.Load())
-	m.currentGame = g
+	if rule == nil {
+		rule = r.Rule()
+	}
+	g := NewGame(r.ID(), dealer, rule, r.nextShoe(), r.MaxBet(), r.Timeout())
+	r.setGame(g)
 	f := m.onNewGameFunc
 	m.mu.Unlock()
 
+	if window := r.BettingWindow(); window > 0 {
+		g.setDealAt(time.Now().Add(window))
+		m.startCountdown(g)
+	}
+	m.saveRoom(context.TODO(), r)
+
 	if f != nil {
 		f(g)
 	}
 	return g, nil
 }
 
+// SetPlayerRule saves the preferred rule of the player
+func (m *Manager) SetPlayerRule(ctx context.Context, p *model.Player, ruleID string) (*Rule, error) {
+	r, ok := Rules.Get(ruleID)
+	if !ok {
+		return nil, fmt.Errorf("không tìm thấy rule: %s", ruleID)
+	}
+	if p.RuleID == ruleID {
+		return &r, nil
+	}
+
+	p.RuleID = ruleID
+	if err := m.store.SavePlayer(ctx, p); err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
 func (m *Manager) PlayerBet(ctx context.Context, gameID string, p *model.Player, amount uint64) (err error) {
 	m.mu.RLock()
-	g := m.currentGame
+	g := m.findGame(gameID)
+	r := m.players[p.ID]
 	f := m.onPlayerBetFunc
 	m.mu.RUnlock()
 
-	if g == nil || g.ID() != gameID {
+	if g == nil {
 		return ErrGameNotFound
 	}
+	if r == nil || r.ID() != g.RoomID() {
+		return ErrNotInRoom
+	}
 
 	var pg *PlayerInGame
 	if amount == 0 {
@@ -244,11 +388,15 @@ func (m *Manager) PlayerBet(ctx context.Context, gameID string, p *model.Player,
 			return err
 		}
 	} else {
+		if err = m.refreshDealer(ctx, g); err != nil {
+			return err
+		}
 		pg, err = g.PlayerBet(p, amount)
 		if err != nil {
 			return err
 		}
 	}
+	m.saveRoom(ctx, r)
 
 	if f != nil {
 		f(g, pg)
@@ -265,7 +413,7 @@ func (m *Manager) PlayerStand(ctx context.Context, g *Game, pg *PlayerInGame) er
 		return ErrYouCannotStand
 	}
 	if err := g.PlayerStand(pg); err != nil {
-		log.Ctx(ctx).Err(err).Str("cards", pg.Cards().String(false)).Msg("player stand failed")
+		log.Ctx(ctx).Err(err).Str("cards", pg.CardsInfo()).Msg("player stand failed")
 		return err
 	}
 
@@ -277,10 +425,9 @@ func (m *Manager) PlayerStand(ctx context.Context, g *Game, pg *PlayerInGame) er
 		f(g, pg)
 	}
 
-	if _, err := g.PlayerNext(); err != nil {
-		return err
-	}
-	return nil
+	_, err := g.PlayerNext()
+	m.saveGame(ctx, g)
+	return err
 }
 
 func (m *Manager) PlayerHit(ctx context.Context, g *Game, pg *PlayerInGame) error {
@@ -288,12 +435,12 @@ func (m *Manager) PlayerHit(ctx context.Context, g *Game, pg *PlayerInGame) erro
 		return ErrYouCannotHit
 	}
 
-	c, err := g.RemoveCard()
-	if err != nil {
+	if _, err := g.Hit(pg); err != nil {
 		return err
 	}
-	pg.AddCard(c)
 	pg.SetLastHit(time.Now().Unix())
+	m.saveGame(ctx, g)
+	m.scheduleTurn(g, pg, time.Now())
 
 	m.mu.RLock()
 	f := m.onPlayerHitFunc
@@ -309,20 +456,106 @@ func (m *Manager) CheckIfFinish(ctx context.Context, g *Game) bool {
 	if !g.Finished() {
 		return false
 	}
-	err := m.FinishGame(ctx, g, false)
+	// nobody plays a finished game any more, e.g. the dealer passed, so the reveal limits cannot be met
+	err := m.FinishGame(ctx, g, true)
 	return err == nil
 }
 
 func (m *Manager) Deal(ctx context.Context, gameID string) (*Game, error) {
 	m.mu.RLock()
-	g := m.currentGame
+	g := m.findGame(gameID)
 	m.mu.RUnlock()
 
-	if g == nil || g.ID() != gameID {
+	if g == nil {
 		return nil, ErrGameNotFound
 	}
 
+	if err := m.refreshDealer(ctx, g); err != nil {
+		return nil, err
+	}
+	m.watchGame(g)
+	// escrow runs under the game lock, so the id is taken before
+	id := g.ID()
+	err := g.deal(func(bets map[string]uint64) error {
+		return m.store.EscrowBets(ctx, id, bets)
+	})
+	if err != nil {
+		return nil, err
+	}
+	m.betting.stop(id)
+	m.saveGame(ctx, g)
+	return g, nil
+}
+
+// refreshDealer reads the dealer's balance from the store, bets are only accepted against what the dealer still has
+func (m *Manager) refreshDealer(ctx context.Context, g *Game) error {
+	d, err := m.store.GetPlayerByID(ctx, g.Dealer().ID)
+	if err != nil {
+		return err
+	}
+	g.setDealerBalance(d.Balance)
+	return nil
+}
+
+// startCountdown deals the game automatically when its betting window ends, the board is refreshed
+// only when the countdown shown on it changes
+func (m *Manager) startCountdown(g *Game) {
+	shown := g.Countdown()
+	m.betting.start(g.ID(), g.DealAt(), BettingTick, func(left time.Duration) {
+		if g.Status() != Betting {
+			return
+		}
+		c := g.Countdown()
+		if c == shown {
+			return
+		}
+		shown = c
+		m.mu.RLock()
+		f := m.onBettingTickFunc
+		m.mu.RUnlock()
+		if f != nil {
+			f(g, left)
+		}
+	}, func() {
+		m.expireBetting(context.Background(), g)
+	})
+}
+
+// expireBetting deals the game if anyone bet, otherwise cancels it
+func (m *Manager) expireBetting(ctx context.Context, g *Game) {
+	if g.Status() != Betting {
+		return
+	}
+	dealt := false
+	if len(g.PlayersInGame()) > 0 {
+		if _, err := m.Deal(ctx, g.ID()); err != nil {
+			log.Ctx(ctx).Err(err).Str("game_id", g.ID()).Msg("auto deal failed")
+			// the dealer dealt the game meanwhile
+			if g.Status() != Betting {
+				return
+			}
+		} else {
+			dealt = true
+		}
+	}
+	if !dealt {
+		if _, err := m.CancelGame(ctx, g.ID()); err != nil {
+			log.Ctx(ctx).Err(err).Str("game_id", g.ID()).Msg("auto cancel failed")
+			return
+		}
+	}
+
+	m.mu.RLock()
+	f := m.onBettingTimeoutFunc
+	m.mu.RUnlock()
+	if f != nil {
+		f(g, dealt)
+	}
+}
+
+func (m *Manager) watchGame(g *Game) {
 	g.OnPlayerPlay(func(pg *PlayerInGame) {
+		m.scheduleTurn(g, pg, time.Now())
 		m.mu.RLock()
 		f := m.onPlayerPlayFunc
 		m.mu.RUnlock()
@@ -330,10 +563,6 @@ func (m *Manager) Deal(ctx context.Context, gameID string) (*Game, error) {
 			f(g, pg)
 		}
 	})
-	if err := g.Deal(); err != nil {
-		return nil, err
-	}
-	return g, nil
 }
 
 func (m *Manager) Start(ctx context.Context, g *Game) error {
@@ -356,50 +585,300 @@ func (m *Manager) Start(ctx context.Context, g *Game) error {
 		return m.FinishGame(ctx, g, true)
 	}
 
-	if _, err := g.PlayerNext(); err != nil {
-		return err
+	_, err := g.PlayerNext()
+	m.saveGame(ctx, g)
+	return err
+}
+
+// Compare reveals the participant's cards and settles them against the dealer
+func (m *Manager) Compare(ctx context.Context, g *Game, to *PlayerInGame) (int64, error) {
+	g.finishMu.Lock()
+	defer g.finishMu.Unlock()
+	reward, err := g.Done(to, false)
+	if err != nil {
+		return 0, err
+	}
+	// revealing counts as an action of the dealer
+	if !g.Finished() {
+		g.Dealer().SetLastHit(time.Now().Unix())
+		m.scheduleTurn(g, g.Dealer(), time.Now())
+	}
+	m.saveGame(ctx, g)
+	return reward, nil
+}
+
+// Reveal is a participant revealed by the dealer, Reward is the dealer's reward
+type Reveal struct {
+	Player *PlayerInGame
+	Reward int64
+}
+
+// RevealAll compares the dealer against every remaining participant holding at least minCards cards, in order.
+// Participants the rule does not allow to reveal yet are skipped.
+func (m *Manager) RevealAll(ctx context.Context, g *Game, minCards int) ([]Reveal, error) {
+	g.finishMu.Lock()
+	defer g.finishMu.Unlock()
+	var res []Reveal
+	for _, pg := range g.PlayersInGame() {
+		if !g.CanReveal(pg) || len(pg.Cards()) < minCards {
+			continue
+		}
+		reward, err := g.Done(pg, false)
+		if err != nil {
+			// keep the reveals made so far, a restore replays the saved state
+			if len(res) > 0 {
+				m.saveGame(ctx, g)
+			}
+			return res, err
+		}
+		res = append(res, Reveal{Player: pg, Reward: reward})
+	}
+	if len(res) == 0 {
+		return nil, ErrNoPlayerToReveal
+	}
+
+	if !g.Finished() {
+		g.Dealer().SetLastHit(time.Now().Unix())
+		m.scheduleTurn(g, g.Dealer(), time.Now())
+	}
+	m.saveGame(ctx, g)
+	return res, nil
+}
+
+// scheduleTurn restarts the turn timer of the game for pg, whose turn started or last acted at since
+func (m *Manager) scheduleTurn(g *Game, pg *PlayerInGame, since time.Time) {
+	timeout := g.TurnTimeout(pg)
+	if timeout <= 0 {
+		return
+	}
+	m.turns.start(g.ID(), timeout, since.Add(timeout), func(left time.Duration) {
+		m.mu.RLock()
+		f := m.onTurnWarningFunc
+		m.mu.RUnlock()
+		if f != nil {
+			f(g, pg, left)
+		}
+	}, func() {
+		m.expireTurn(context.Background(), g, pg)
+	})
+}
+
+// expireTurn stands the participant who timed out if they can, otherwise passes them.
+// A dealer who timed out reveals all remaining participants.
+func (m *Manager) expireTurn(ctx context.Context, g *Game, pg *PlayerInGame) {
+	if g.Finished() || g.CurrentPlaying() != pg || pg.Status() != PlayerPlaying {
+		return
+	}
+	stood := !pg.IsDealer() && pg.CanStand()
+
+	m.mu.RLock()
+	f := m.onTurnTimeoutFunc
+	m.mu.RUnlock()
+	if f != nil {
+		f(g, pg, stood)
+	}
+
+	log.Ctx(ctx).Info().Str("game_id", g.ID()).Str("player_id", pg.ID).Bool("stood", stood).Msg("turn timed out")
+	var err error
+	if stood {
+		err = m.PlayerStand(ctx, g, pg)
+	} else if err = g.pass(pg); err == nil {
+		m.saveGame(ctx, g)
+		if pg.IsDealer() {
+			err = m.FinishGame(ctx, g, true)
+		} else {
+			m.CheckIfFinish(ctx, g)
+		}
+	}
+	if err != nil {
+		log.Ctx(ctx).Err(err).Str("game_id", g.ID()).Str("player_id", pg.ID).Msg("timeout turn failed")
 	}
-	return nil
 }
 
+// FinishGame settles the remaining participants and pays the game, it returns ErrGameFinished if the game was
+// already finished or was settled before a restart, which is then only cleared from the room. The turn timer, the dealer's commands and the buttons may all finish the same game, so they
+// are serialized by the game.
 func (m *Manager) FinishGame(ctx context.Context, g *Game, force bool) error {
+	g.finishMu.Lock()
+	defer g.finishMu.Unlock()
+	if g.finished {
+		return ErrGameFinished
+	}
+	if !force && !g.CanFinish() {
+		return ErrRevealNotAllowed
+	}
+
 	for _, pg := range g.PlayersInGame() {
-		if _, err := g.Done(pg, force); err != nil {
+		if _, err := g.settle(pg, force); err != nil {
 			return err
 		}
 	}
 
+	now := time.Now()
 	items := g.ResultMap()
+	rewards := make(map[string]int64, len(items))
+	records := make([]model.Record, 0, len(items))
 	for _, item := range items {
-		if err := m.store.SaveRecord(ctx, &model.Record{
+		rewards[item.PlayerID] += item.Reward
+		records = append(records, model.Record{
 			GameID:     g.ID(),
 			PlayerID:   item.PlayerID,
 			Reward:     item.Reward,
 			IsDealer:   item.IsDealer,
 			ResultType: item.ResultType,
 			Value:      item.Value,
-		}); err != nil {
-			return err
-		}
+			Bet:        item.Bet,
+			StartValue: item.StartValue,
+			CreatedAt:  now,
+		})
 	}
+	// a game is only paid and recorded by the finish that settles it, one settled before a restart is only
+	// cleared from the room
+	repayments, err := m.store.SettleGame(ctx, g.ID(), rewards, records, g.Record(model.GameFinished, now))
+	settled := err == nil
+	if err != nil && !errors.Is(err, model.ErrAlreadySettled) {
+		return err
+	}
+	g.finished = true
+
+	m.saveProof(ctx, g)
+	if settled {
+		log.Ctx(ctx).Info().Str("game_id", g.ID()).Str("seed", g.Seed()).Int("shoe_offset", g.ShoeOffset()).Msg("game finished")
+		g.record(model.GameEvent{Type: model.EventFinish})
+		m.saveEvents(ctx, g)
+	}
+	m.turns.stop(g.ID())
 
 	m.mu.Lock()
 	f := m.onGameFinishFunc
-	m.currentGame = nil
+	onRepaid := m.onLoanRepaidFunc
+	r := m.rooms[g.RoomID()]
+	if r != nil {
+		r.clearGame(g)
+	}
 	m.mu.Unlock()
 
+	if r != nil {
+		m.saveRoom(ctx, r)
+	}
+	if !settled {
+		return ErrGameFinished
+	}
+
 	if f != nil {
 		f(g)
 	}
+	if onRepaid != nil {
+		for _, rp := range repayments {
+			onRepaid(rp)
+		}
+	}
 	return nil
 }
 
-func (m *Manager) CancelGame(ctx context.Context) error {
+// CancelGame cancels the game and refunds its bets, it returns ErrGameNotFound if the game is no longer running.
+// The game is put back if the refund fails.
+func (m *Manager) CancelGame(ctx context.Context, gameID string) (*Game, error) {
 	m.mu.Lock()
-	m.currentGame = nil
+	g := m.findGame(gameID)
+	if g == nil {
+		m.mu.Unlock()
+		return nil, ErrGameNotFound
+	}
+	r := m.rooms[g.RoomID()]
+	r.clearGame(g)
 	m.mu.Unlock()
 
-	return nil
+	m.turns.stop(g.ID())
+	m.betting.stop(g.ID())
+	// refund the escrowed bets if the game was dealt
+	if _, err := m.store.SettleGame(ctx, g.ID(), nil, nil, g.Record(model.GameCancelled, time.Now())); errors.Is(err, model.ErrAlreadySettled) {
+		// the game was finished meanwhile
+		return nil, ErrGameNotFound
+	} else if err != nil {
+		m.mu.Lock()
+		restored := r.Game() == nil
+		if restored {
+			r.setGame(g)
+		}
+		m.mu.Unlock()
+		if restored {
+			m.resumeTimers(g)
+		}
+		return nil, err
+	}
+	g.record(model.GameEvent{Type: model.EventCancel})
+	m.saveRoom(ctx, r)
+	m.saveEvents(ctx, g)
+	m.saveProof(ctx, g)
+	return g, nil
+}
+
+// AddClientSeed adds the player's seed to the shuffle of the game they are betting in
+func (m *Manager) AddClientSeed(ctx context.Context, p *model.Player, seed string) (*Game, error) {
+	g := m.CurrentGame(p.ID)
+	if g == nil {
+		return nil, ErrGameNotFound
+	}
+	if err := g.AddClientSeed(p, seed); err != nil {
+		return nil, err
+	}
+	m.saveGame(ctx, g)
+	return g, nil
+}
+
+// VerifyGame re-derives the shuffle of a finished game from its revealed seeds
+func (m *Manager) VerifyGame(ctx context.Context, gameID string) (string, error) {
+	if m.isRunning(gameID) {
+		return "", ErrGameNotFinished
+	}
+	proof, err := m.store.GetGameProof(ctx, gameID)
+	if err != nil {
+		if model.IsNotFound(err) {
+			return "", ErrProofNotFound
+		}
+		return "", err
+	}
+	cards, err := VerifyShuffle(proof)
+	if err != nil {
+		return "", err
+	}
+
+	bf := bytes.NewBuffer(nil)
+	bf.WriteString(fmt.Sprintf("Ván `%s`: seed khớp với mã cam kết ✅\n", proof.GameID))
+	bf.WriteString(fmt.Sprintf("- Mã cam kết: `%s`\n", proof.Commitment))
+	bf.WriteString(fmt.Sprintf("- Seed: `%s`\n", hex.EncodeToString(proof.ServerSeed)))
+	bf.WriteString(fmt.Sprintf("- Seed người chơi (%d):", len(proof.ClientSeeds)))
+	for _, cs := range proof.ClientSeeds {
+		name := cs.PlayerID
+		if p := m.findPlayer(ctx, cs.PlayerID); p != nil {
+			name = p.Name
+		}
+		bf.WriteString(fmt.Sprintf("\n  - %s: `%s`", name, cs.Seed))
+	}
+	ss := make([]string, len(cards))
+	for i := range cards {
+		ss[i] = cards[i].String()
+	}
+	bf.WriteString(fmt.Sprintf("\n- Thứ tự bài (%d bộ): %s", proof.Decks, strings.Join(ss, ", ")))
+	return bf.String(), nil
+}
+
+// SetBettingWindow sets the betting window of new rooms, zero lets the dealer deal by hand
+func (m *Manager) SetBettingWindow(window time.Duration) {
+	m.window.Store(window)
+}
+
+// SetFaucet sets the daily bonus and the relief granted to the players, both are disabled until it is set
+func (m *Manager) SetFaucet(cfg FaucetConfig) {
+	m.faucet.Store(&cfg)
+}
+
+func (m *Manager) faucetConfig() FaucetConfig {
+	if cfg := m.faucet.Load(); cfg != nil {
+		return *cfg
+	}
+	return FaucetConfig{}
 }
 
 func (m *Manager) SetMaxBet(maxBet uint64) uint64 {
@@ -407,11 +886,8 @@ func (m *Manager) SetMaxBet(maxBet uint64) uint64 {
 	return maxBet
 }
 
-func (m *Manager) PlayerPass(ctx context.Context) (*PlayerInGame, error) {
-	m.mu.RLock()
-	g := m.currentGame
-	m.mu.RUnlock()
-
+func (m *Manager) PlayerPass(ctx context.Context, p *model.Player) (*PlayerInGame, error) {
+	g := m.CurrentGame(p.ID)
 	if g == nil {
 		return nil, ErrGameNotFound
 	}
@@ -423,6 +899,7 @@ func (m *Manager) PlayerPass(ctx context.Context) (*PlayerInGame, error) {
 	if err := g.Pass(pg); err != nil {
 		return nil, err
 	}
+	m.saveGame(ctx, g)
 	m.CheckIfFinish(ctx, g)
 	return pg, nil
 }
@@ -437,140 +914,1293 @@ func (m *Manager) Resume(ctx context.Context) error {
 	return nil
 }
 
-func (m *Manager) Deposit(ctx context.Context, id string, amount int64) (*model.Player, error) {
+func (m *Manager) Deposit(ctx context.Context, operator *model.Player, id string, amount int64) (*model.Player, error) {
 	p := m.findPlayer(ctx, id)
 	if p == nil {
 		return nil, ErrPlayerNotFound
 	}
 
-	return m.store.AddPlayerBalance(ctx, p.ID, amount)
+	return m.store.AddPlayerBalance(ctx, p.ID, amount, operator.ID)
 }
 
-func (m *Manager) PlayerHistory(ctx context.Context, p *model.Player) string {
-	records, err := m.store.ListRecords(ctx, p.ID, 10)
+// PlayerStatement lists the latest ledger entries of the player with the balance after each of them
+func (m *Manager) PlayerStatement(ctx context.Context, p *model.Player, limit int) (string, error) {
+	p, err := m.store.GetPlayerByID(ctx, p.ID)
 	if err != nil {
-		return err.Error()
+		return "", err
+	}
+	entries, err := m.store.ListLedgerEntries(ctx, p.ID, limit)
+	if err != nil {
+		return "", err
 	}
 
 	bf := bytes.NewBuffer(nil)
-	for _, r := range records {
-		bf.WriteString(fmt.Sprintf("%s %s\n", r.ResultType, stringer.FormatCurrency(r.Reward)))
+	bf.WriteString(fmt.Sprintf("Sao kê %d giao dịch gần nhất, số dư: %s\n\n", len(entries), stringer.FormatCurrency(p.Balance)))
+	balance := p.Balance
+	for _, e := range entries {
+		bf.WriteString(fmt.Sprintf("`%s` %s %s", e.CreatedAt.Format("02/01 15:04"), e.Reason, stringer.FormatCurrency(e.Amount)))
+		if len(e.Reference) > 0 {
+			bf.WriteString(fmt.Sprintf(" (`%s`)", e.Reference))
+		}
+		bf.WriteString(fmt.Sprintf(" → %s\n", stringer.FormatCurrency(balance)))
+		balance -= e.Amount
 	}
-	return bf.String()
+	return bf.String(), nil
 }
 
-type Stat struct {
-	Count int
-	Sum   int64
+const (
+	// LoanReminderLead is how long before the due date the borrower is first reminded
+	LoanReminderLead = 24 * time.Hour
+	// LoanReminderInterval is the least time between two reminders of a loan
+	LoanReminderInterval = 24 * time.Hour
+)
+
+// Give transfers amount from p to the recipient and returns both of them with their new balances
+func (m *Manager) Give(ctx context.Context, p, to *model.Player, amount int64) (from, recipient *model.Player, err error) {
+	if p.ID == to.ID {
+		return nil, nil, ErrPlayYourself
+	}
+	if amount <= 0 {
+		return nil, nil, ErrInvalidAmount
+	}
+	return m.store.Transfer(ctx, p.ID, to.ID, amount)
 }
 
-func (m *Manager) PlayerStats(ctx context.Context, p *model.Player) string {
-	size := 1000
-	records, err := m.store.ListRecords(ctx, p.ID, size)
-	if err != nil {
-		return err.Error()
+// Lend pays amount from p to the borrower and tracks the debt until it is repaid, a zero due means no due date.
+// The loan is repaid from the borrower's winnings if auto is set.
+func (m *Manager) Lend(ctx context.Context, p, borrower *model.Player, amount int64, due time.Time, auto bool) (*model.Loan, error) {
+	if p.ID == borrower.ID {
+		return nil, ErrPlayYourself
+	}
+	if amount <= 0 {
+		return nil, ErrInvalidAmount
+	}
+	now := time.Now()
+	if !due.IsZero() && !due.After(now) {
+		return nil, ErrInvalidDueDate
+	}
+	l := &model.Loan{
+		ID:         xid.New().String(),
+		LenderID:   p.ID,
+		BorrowerID: borrower.ID,
+		Amount:     amount,
+		AutoRepay:  auto,
+		DueAt:      due,
+		CreatedAt:  now,
 	}
+	if err := m.store.CreateLoan(ctx, l); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
 
-	stats := map[model.ResultType]Stat{}
-	values := map[int]Stat{}
-	sum := int64(0)
-	for _, r := range records {
-		sum += r.Reward
+// Repay pays back the loans of p from the lender oldest first, everything owed when amount is 0.
+// It returns the amount paid and what is still owed to the lender.
+func (m *Manager) Repay(ctx context.Context, p, lender *model.Player, amount int64) (paid, owed int64, err error) {
+	if amount < 0 {
+		return 0, 0, ErrInvalidAmount
+	}
+	repayments, err := m.store.RepayLoans(ctx, p.ID, lender.ID, amount, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(repayments) == 0 {
+		return 0, 0, ErrNoDebt
+	}
+	for _, r := range repayments {
+		paid += r.Amount
+	}
 
-		if r.ResultType > model.TypeNormal {
-			continue
+	loans, err := m.store.ListLoans(ctx, p.ID)
+	if err != nil {
+		return paid, 0, err
+	}
+	for _, l := range loans {
+		if l.BorrowerID == p.ID && l.LenderID == lender.ID {
+			owed += l.Outstanding()
 		}
+	}
+	return paid, owed, nil
+}
 
-		if r.ResultType == model.TypeNormal {
-			values[r.Value] = Stat{
-				Count: values[r.Value].Count + 1,
-				Sum:   values[r.Value].Sum + r.Reward,
-			}
-			continue
-		}
+// Debts lists the open loans lent and borrowed by p
+func (m *Manager) Debts(ctx context.Context, p *model.Player) (string, error) {
+	loans, err := m.store.ListLoans(ctx, p.ID)
+	if err != nil {
+		return "", err
+	}
+	if len(loans) == 0 {
+		return "Bạn không có khoản vay nào", nil
+	}
 
-		stats[r.ResultType] = Stat{
-			Count: stats[r.ResultType].Count + 1,
-			Sum:   stats[r.ResultType].Sum + r.Reward,
+	var lent, borrowed []model.Loan
+	for _, l := range loans {
+		if l.LenderID == p.ID {
+			lent = append(lent, l)
+		} else {
+			borrowed = append(borrowed, l)
 		}
 	}
-
+	now := time.Now()
 	bf := bytes.NewBuffer(nil)
-	bf.WriteString(fmt.Sprintf("Thống kê %d ván gần nhất:\n\n", len(records)))
-	sumD := int64(0)
-	cntD := 0
-	for k, v := range stats {
-		if k >= model.TypeNormal {
+	for _, part := range []struct {
+		title string
+		loans []model.Loan
+	}{{"Bạn đang nợ", borrowed}, {"Bạn đang cho vay", lent}} {
+		if len(part.loans) == 0 {
 			continue
 		}
-		cntD += v.Count
-		sumD += v.Sum
-		bf.WriteString(fmt.Sprintf("`%s`: %d ván, %s\n", k, v.Count, stringer.FormatCurrency(v.Sum)))
-	}
-	for k, v := range values {
-		if k < 20 {
-			continue
+		total := int64(0)
+		for _, l := range part.loans {
+			total += l.Outstanding()
+		}
+		bf.WriteString(fmt.Sprintf("%s %s:\n", part.title, stringer.FormatCurrency(total)))
+		for _, l := range part.loans {
+			id := l.BorrowerID
+			if l.LenderID != p.ID {
+				id = l.LenderID
+			}
+			name := id
+			if op := m.findPlayer(ctx, id); op != nil {
+				name = op.Name
+			}
+			bf.WriteString(fmt.Sprintf("- `%s`: %s", name, stringer.FormatCurrency(l.Outstanding())))
+			if l.Repaid > 0 {
+				bf.WriteString(fmt.Sprintf(" (vay %s)", stringer.FormatCurrency(l.Amount)))
+			}
+			if !l.DueAt.IsZero() {
+				bf.WriteString(", hạn " + l.DueAt.Format("02/01 15:04"))
+				if l.Overdue(now) {
+					bf.WriteString(" ⚠️ quá hạn")
+				}
+			}
+			if l.AutoRepay {
+				bf.WriteString(", tự trừ khi thắng")
+			}
+			bf.WriteString("\n")
 		}
-		cntD += v.Count
-		sumD += v.Sum
-		bf.WriteString(fmt.Sprintf("`%d`: %d ván, %s\n", k, v.Count, stringer.FormatCurrency(v.Sum)))
+		bf.WriteString("\n")
 	}
-	bf.WriteString(fmt.Sprintf("`Khác`: %d ván, %s\n", len(records)-cntD, stringer.FormatCurrency(sum-sumD)))
-	bf.WriteString(fmt.Sprintf("\nTổng: %s\n", stringer.FormatCurrency(sum)))
-	return bf.String()
+	return bf.String(), nil
 }
 
-func (m *Manager) ListPlayers(ctx context.Context) string {
-	players, err := m.store.ListPlayers(ctx)
+// RemindLoans returns the open loans which are due soon or overdue and whose borrower
+// was not reminded lately, they are marked as reminded at now
+func (m *Manager) RemindLoans(ctx context.Context, now time.Time) []model.Loan {
+	loans, err := m.store.ListDueLoans(ctx, now.Add(LoanReminderLead))
 	if err != nil {
-		return err.Error()
+		log.Ctx(ctx).Err(err).Msg("list due loans failed")
+		return nil
+	}
+	var due []model.Loan
+	for _, l := range loans {
+		if now.Sub(l.RemindedAt) < LoanReminderInterval {
+			continue
+		}
+		if err := m.store.MarkLoanReminded(ctx, l.ID, now); err != nil {
+			log.Ctx(ctx).Err(err).Str("loan_id", l.ID).Msg("mark loan reminded failed")
+			continue
+		}
+		l.RemindedAt = now
+		due = append(due, l)
+	}
+	return due
+}
+
+// ClaimDaily grants p the daily bonus, which grows with the number of consecutive days claimed
+func (m *Manager) ClaimDaily(ctx context.Context, p *model.Player) (string, error) {
+	cfg := m.faucetConfig()
+	now := time.Now()
+	var bonus int64
+	var streak int
+	p, err := m.store.UpdateFaucet(ctx, p.ID, model.LedgerDaily, func(f *model.Faucet, balance int64) (int64, error) {
+		var err error
+		bonus, err = cfg.claimDaily(f, now)
+		streak = f.Streak
+		return bonus, err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	bf := bytes.NewBuffer(nil)
+	bf.WriteString(fmt.Sprintf("🎁 Bạn nhận được %s, chuỗi %d ngày liên tiếp. Số dư: %s\n",
+		stringer.FormatCurrency(bonus), streak, stringer.FormatCurrency(p.Balance)))
+	if next := cfg.DailyBonus(streak + 1); next > bonus {
+		bf.WriteString(fmt.Sprintf("Quay lại vào ngày mai để nhận %s", stringer.FormatCurrency(next)))
+	}
+	return bf.String(), nil
+}
+
+// GrantReliefs grants the relief to the active players whose balance has stayed too low, they are returned with their new balance
+func (m *Manager) GrantReliefs(ctx context.Context, now time.Time) []model.Player {
+	cfg := m.faucetConfig()
+	if cfg.ReliefAmount <= 0 {
+		return nil
+	}
+	players, err := m.store.ListActivePlayers(ctx)
+	if err != nil {
+		log.Ctx(ctx).Err(err).Msg("list players failed")
+		return nil
+	}
+
+	var granted []model.Player
+	for _, p := range players {
+		if p.IsBot() {
+			continue
+		}
+		if p.Balance >= cfg.ReliefThreshold {
+			// only the players who were under the threshold have a state to clear
+			f, err := m.store.GetFaucet(ctx, p.ID)
+			if err != nil {
+				log.Ctx(ctx).Err(err).Str("player_id", p.ID).Msg("get faucet failed")
+				continue
+			}
+			if f.BrokeSince.IsZero() {
+				continue
+			}
+		}
+		amount := int64(0)
+		np, err := m.store.UpdateFaucet(ctx, p.ID, model.LedgerRelief, func(f *model.Faucet, balance int64) (int64, error) {
+			amount = cfg.relief(f, balance, now)
+			return amount, nil
+		})
+		if err != nil {
+			log.Ctx(ctx).Err(err).Str("player_id", p.ID).Msg("grant relief failed")
+			continue
+		}
+		if amount > 0 {
+			log.Ctx(ctx).Info().Str("player_id", p.ID).Int64("amount", amount).Msg("relief granted")
+			granted = append(granted, *np)
+		}
+	}
+	return granted
+}
+
+// BlockFaucet stops or allows again the daily bonus and the relief of the player
+func (m *Manager) BlockFaucet(ctx context.Context, id string, blocked bool) (*model.Player, error) {
+	p := m.findPlayer(ctx, id)
+	if p == nil {
+		return nil, ErrPlayerNotFound
+	}
+	return m.store.UpdateFaucet(ctx, p.ID, model.LedgerRelief, func(f *model.Faucet, balance int64) (int64, error) {
+		f.Blocked = blocked
+		return 0, nil
+	})
+}
+
+// ReconcileLedger checks the balance of every player against the sum of the player's ledger entries
+// and that all entries sum to zero
+func (m *Manager) ReconcileLedger(ctx context.Context) (string, error) {
+	players, err := m.store.ListPlayers(ctx)
+	if err != nil {
+		return "", err
+	}
+	balances, err := m.store.LedgerBalances(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	bf := bytes.NewBuffer(nil)
+	mismatches := 0
+	for _, p := range players {
+		if p.Balance == balances[p.ID] {
+			continue
+		}
+		mismatches++
+		bf.WriteString(fmt.Sprintf("- `%s` (`%s`): số dư %s, sổ cái %s\n", p.Name, p.ID,
+			stringer.FormatCurrency(p.Balance), stringer.FormatCurrency(balances[p.ID])))
+	}
+	total := int64(0)
+	for _, b := range balances {
+		total += b
+	}
+
+	res := fmt.Sprintf("Đối soát %d người chơi: %d lệch\n", len(players), mismatches) + bf.String()
+	res += fmt.Sprintf("Đang giữ cược: %s\n", stringer.FormatCurrency(balances[model.AccountEscrow]))
+	if total != 0 {
+		res += fmt.Sprintf("‼️ Sổ cái không cân: %s\n", stringer.FormatCurrency(total))
+	} else if mismatches == 0 {
+		res += "✅ Sổ cái khớp\n"
+	}
+	return res, nil
+}
+
+// HistoryPageSize is the number of games on a page of the history
+const HistoryPageSize = 5
+
+// HistoryPage is a page of the games of a player, newest first
+type HistoryPage struct {
+	Text    string
+	GameIDs []string      // games on the page, in the order of the text
+	Prev    *model.Cursor // newer games, nil on the first page
+	Next    *model.Cursor // older games, nil on the last page
+}
+
+// PlayerHistory lists the games of the player ended in [from, to) page by page, zero times mean no limit
+func (m *Manager) PlayerHistory(ctx context.Context, p *model.Player, from, to time.Time, cursor model.Cursor) (*HistoryPage, error) {
+	records, err := m.store.ListRecords(ctx, p.ID, from, to, cursor, HistoryPageSize+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &HistoryPage{}
+	more := len(records) > HistoryPageSize
+	if more && cursor.After > 0 {
+		records = records[1:]
+	} else if more {
+		records = records[:HistoryPageSize]
+	}
+	if len(records) > 0 {
+		if cursor.Before > 0 || cursor.After > 0 && more {
+			page.Prev = &model.Cursor{After: records[0].ID}
+		}
+		if cursor.After > 0 || more {
+			page.Next = &model.Cursor{Before: records[len(records)-1].ID}
+		}
+	}
+
+	bf := bytes.NewBuffer(nil)
+	if len(records) == 0 {
+		bf.WriteString(fmt.Sprintf("Chưa có ván nào%s", stringer.FormatDateRange(from, to)))
+	} else {
+		bf.WriteString(fmt.Sprintf("Lịch sử chơi%s:\n\n", stringer.FormatDateRange(from, to)))
+	}
+	for i, r := range records {
+		bf.WriteString(fmt.Sprintf("%d. %s\n", i+1, m.historyEntry(ctx, p, r)))
+		page.GameIDs = append(page.GameIDs, r.GameID)
+	}
+	page.Text = bf.String()
+	return page, nil
+}
+
+// historyEntry shows the cards of the player and the opponents in the game, it falls back to
+// the result only when the game has no events
+func (m *Manager) historyEntry(ctx context.Context, p *model.Player, r model.Record) string {
+	date := ""
+	if !r.CreatedAt.IsZero() {
+		date = "`" + r.CreatedAt.Format("02/01 15:04") + "` "
+	}
+	short := fmt.Sprintf("%s%s %s", date, r.ResultType, stringer.FormatCurrency(r.Reward))
+
+	events, err := m.store.ListGameEvents(ctx, r.GameID)
+	if err != nil || len(events) == 0 {
+		return short
+	}
+	g, err := FoldEvents(events, func(id string) *model.Player {
+		return m.findPlayer(ctx, id)
+	})
+	if err != nil {
+		return short
+	}
+	pg := g.FindPlayer(p.ID)
+	if pg == nil {
+		return short
+	}
+
+	bf := bytes.NewBuffer(nil)
+	dealer := g.Dealer()
+	if pg.IsDealer() {
+		bf.WriteString(fmt.Sprintf("%sBạn làm cái, %d người chơi\n", date, len(g.PlayersInGame())))
+		bf.WriteString(fmt.Sprintf("   Bài bạn: %s\n", pg.CardsInfo()))
+		for _, op := range g.PlayersInGame() {
+			bf.WriteString(fmt.Sprintf("   `%s`: %s, %s\n", op.Name, op.CardsInfo(), stringer.FormatCurrency(-op.Reward())))
+		}
+		bf.WriteString(fmt.Sprintf("   Tổng: %s", stringer.FormatCurrency(r.Reward)))
+		return bf.String()
+	}
+
+	bf.WriteString(fmt.Sprintf("%sNhà cái `%s`\n", date, dealer.Name))
+	bf.WriteString(fmt.Sprintf("   Bài bạn: %s\n", pg.CardsInfo()))
+	bf.WriteString(fmt.Sprintf("   Nhà cái: %s\n", dealer.CardsInfo()))
+	bf.WriteString(fmt.Sprintf("   Cược %s", stringer.FormatCurrency(int64(pg.BetAmount()))))
+	if bet := int64(pg.BetAmount()); bet > 0 && r.Reward != 0 {
+		mul := float64(r.Reward) / float64(bet)
+		if mul < 0 {
+			mul = -mul
+		}
+		bf.WriteString(fmt.Sprintf(", x%.3g", mul))
+	}
+	bf.WriteString(fmt.Sprintf(": %s", stringer.FormatCurrency(r.Reward)))
+	return bf.String()
+}
+
+// PlayerStats summarizes the games of the player played in the role and ended in [from, to), zero times mean no limit
+func (m *Manager) PlayerStats(ctx context.Context, p *model.Player, from, to time.Time, role model.StatsRole) string {
+	s, err := m.store.AggregateRecords(ctx, p.ID, from, to, role)
+	if err != nil {
+		return err.Error()
+	}
+
+	bf := bytes.NewBuffer(nil)
+	bf.WriteString(fmt.Sprintf("Thống kê %s%s:\n\n", role, stringer.FormatDateRange(from, to)))
+	if s.Games == 0 {
+		bf.WriteString("Chưa có ván nào\n")
+		return bf.String()
+	}
+	bf.WriteString(fmt.Sprintf("Số ván: %d (thắng %d, hoà %d, thua %d, tỉ lệ thắng %.0f%%)\n",
+		s.Games, s.Wins, s.Draws, s.Losses, s.WinRate()))
+	bf.WriteString(fmt.Sprintf("Lãi/lỗ: %s\n", stringer.FormatCurrency(s.Net)))
+	bf.WriteString(fmt.Sprintf("Thắng đậm nhất: %s\n", stringer.FormatCurrency(s.BiggestWin)))
+	bf.WriteString(fmt.Sprintf("Thua đậm nhất: %s\n", stringer.FormatCurrency(s.BiggestLoss)))
+	if s.BetGames > 0 {
+		bf.WriteString(fmt.Sprintf("Cược trung bình: %s\n", stringer.FormatCurrency(s.AverageBet())))
+	}
+
+	streak := "không có"
+	if s.Streak > 0 {
+		streak = fmt.Sprintf("thắng %d ván", s.Streak)
+	} else if s.Streak < 0 {
+		streak = fmt.Sprintf("thua %d ván", -s.Streak)
+	}
+	bf.WriteString(fmt.Sprintf("Chuỗi hiện tại: %s, dài nhất: thắng %d, thua %d\n", streak, s.LongestWins, s.LongestLosses))
+
+	if len(s.StartValues) > 0 {
+		values := make([]int, 0, len(s.StartValues))
+		for v := range s.StartValues {
+			values = append(values, v)
+		}
+		sort.Ints(values)
+		bf.WriteString("\nTỉ lệ thắng theo bài đầu:\n")
+		for _, v := range values {
+			wr := s.StartValues[v]
+			bf.WriteString(fmt.Sprintf("`%2d`: thắng %d/%d ván (%.0f%%)\n", v, wr.Wins, wr.Games, float64(wr.Wins)*100/float64(wr.Games)))
+		}
+	}
+	return bf.String()
+}
+
+// TopOrder is how the leaderboard is ranked
+type TopOrder uint8
+
+const (
+	TopProfit TopOrder = iota
+	TopGames
+	TopWinRate
+)
+
+func (o TopOrder) String() string {
+	switch o {
+	case TopGames:
+		return "số ván"
+	case TopWinRate:
+		return "tỉ lệ thắng"
+	default:
+		return "lãi/lỗ"
 	}
-	res, _ := json.Marshal(players)
-	return string(res)
+}
+
+const (
+	// TopSize is the number of players on the leaderboard
+	TopSize = 10
+	// TopMinGames is the number of games a player needs to be ranked by win rate
+	TopMinGames = 5
+)
+
+// Leaderboard ranks the players by their games since from, zero from means all time
+func (m *Manager) Leaderboard(ctx context.Context, from time.Time, order TopOrder) (string, error) {
+	totals, err := m.store.Leaderboard(ctx, from)
+	if err != nil {
+		return "", err
+	}
+
+	ranked := make([]model.PlayerTotals, 0, len(totals))
+	for _, t := range totals {
+		if t.Games == 0 || order == TopWinRate && t.Games < TopMinGames {
+			continue
+		}
+		ranked = append(ranked, t)
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		switch {
+		case order == TopGames && a.Games != b.Games:
+			return a.Games > b.Games
+		case order == TopWinRate && a.WinRate() != b.WinRate():
+			return a.WinRate() > b.WinRate()
+		}
+		return a.Net > b.Net
+	})
+
+	bf := bytes.NewBuffer(nil)
+	bf.WriteString(fmt.Sprintf("🏆 Bảng xếp hạng theo %s%s:\n\n", order, stringer.FormatDateRange(from, time.Time{})))
+	rank := 0
+	for _, t := range ranked {
+		p := m.findPlayer(ctx, t.PlayerID)
+		if p == nil || p.IsBot() {
+			continue
+		}
+		rank++
+		bf.WriteString(fmt.Sprintf("%d. `%s`: %s, %d ván, thắng %.0f%%\n", rank, p.Name, stringer.FormatCurrency(t.Net), t.Games, t.WinRate()))
+		if rank == TopSize {
+			break
+		}
+	}
+	if rank == 0 {
+		bf.WriteString("Chưa có ai\n")
+		if order == TopWinRate {
+			bf.WriteString(fmt.Sprintf("Cần chơi ít nhất %d ván để được xếp hạng\n", TopMinGames))
+		}
+	}
+	return bf.String(), nil
+}
+
+// ResetSeason closes the running season with the current balances and starts the next one with the new balance
+func (m *Manager) ResetSeason(ctx context.Context, operator *model.Player, balance int64) (string, error) {
+	s, err := m.store.ResetBalance(ctx, balance, operator.ID)
+	if err != nil {
+		return "", err
+	}
+	log.Ctx(ctx).Info().Int("season", s.Number).Int64("balance", balance).Str("operator_id", operator.ID).Msg("season closed")
+	return seasonText(s, nil) + fmt.Sprintf("\nMùa %d bắt đầu, số dư mọi người: %s\n", s.Number+1, stringer.FormatCurrency(balance)), nil
+}
+
+// Season shows the standings of the season, the running season when number is 0
+func (m *Manager) Season(ctx context.Context, p *model.Player, number int) (string, error) {
+	cur, err := m.store.CurrentSeason(ctx)
+	if err != nil {
+		return "", err
+	}
+	if number > 0 && number != cur.Number {
+		s, err := m.store.GetSeason(ctx, number)
+		if model.IsNotFound(err) || err == nil && !s.Closed {
+			return "", ErrSeasonNotFound
+		} else if err != nil {
+			return "", err
+		}
+		return seasonText(s, p), nil
+	}
+
+	// the running season is ranked by the live balances
+	players, err := m.store.ListPlayers(ctx)
+	if err != nil {
+		return "", err
+	}
+	for _, pl := range players {
+		if !pl.IsBot() {
+			cur.Standings = append(cur.Standings, model.SeasonStanding{PlayerID: pl.ID, Name: pl.Name, Balance: pl.Balance})
+		}
+	}
+	sort.SliceStable(cur.Standings, func(i, j int) bool {
+		return cur.Standings[i].Balance > cur.Standings[j].Balance
+	})
+	for i := range cur.Standings {
+		cur.Standings[i].Rank = i + 1
+		if cur.Standings[i].PlayerID != p.ID {
+			continue
+		}
+		stats, err := m.store.AggregateRecords(ctx, p.ID, cur.StartedAt, time.Time{}, model.StatsAll)
+		if err != nil {
+			return "", err
+		}
+		cur.Standings[i].Games, cur.Standings[i].Wins, cur.Standings[i].Net = stats.Games, stats.Wins, stats.Net
+	}
+	res := seasonText(cur, p)
+	if cur.Number > 1 {
+		res += fmt.Sprintf("\nXem mùa trước: /season %d\n", cur.Number-1)
+	}
+	return res, nil
+}
+
+// seasonText shows the top of the standings and the line of p if they are not in the top
+func seasonText(s *model.Season, p *model.Player) string {
+	bf := bytes.NewBuffer(nil)
+	if s.Closed {
+		bf.WriteString(fmt.Sprintf("🏁 Mùa %d (%s - %s)", s.Number, seasonDate(s.StartedAt), seasonDate(s.EndedAt)))
+	} else {
+		bf.WriteString(fmt.Sprintf("🏆 Mùa %d (từ %s, đang diễn ra)", s.Number, seasonDate(s.StartedAt)))
+	}
+	if s.Balance > 0 {
+		bf.WriteString(fmt.Sprintf(", số dư đầu mùa %s", stringer.FormatCurrency(s.Balance)))
+	}
+	bf.WriteString(":\n\n")
+
+	line := func(st model.SeasonStanding) string {
+		res := fmt.Sprintf("%d. `%s`: %s", st.Rank, st.Name, stringer.FormatCurrency(st.Balance))
+		if st.Games > 0 {
+			res += fmt.Sprintf(" (%d ván, thắng %d, lãi/lỗ %s)", st.Games, st.Wins, stringer.FormatCurrency(st.Net))
+		}
+		return res + "\n"
+	}
+	for _, st := range s.Standings {
+		if st.Rank <= TopSize {
+			bf.WriteString(line(st))
+		} else if p != nil && st.PlayerID == p.ID {
+			bf.WriteString("...\n" + line(st))
+		}
+	}
+	if len(s.Standings) == 0 {
+		bf.WriteString("Chưa có ai\n")
+	}
+	return bf.String()
+}
+
+func seasonDate(t time.Time) string {
+	if t.IsZero() {
+		return "khai trương"
+	}
+	return t.Format("02/01/2006")
+}
+
+// HeadToHeadEncounters is the number of latest encounters shown in the head-to-head record
+const HeadToHeadEncounters = 5
+
+// HeadToHead sums up the games where one of the players dealt and the other bet, from the view of p
+func (m *Manager) HeadToHead(ctx context.Context, p, opponent *model.Player) (string, error) {
+	if p.ID == opponent.ID {
+		return "", ErrPlayYourself
+	}
+	records, err := m.store.ListHeadToHeadRecords(ctx, p.ID, opponent.ID)
+	if err != nil {
+		return "", err
+	}
+
+	bf := bytes.NewBuffer(nil)
+	bf.WriteString(fmt.Sprintf("Đối đầu `%s` - `%s`: %d ván\n", p.Name, opponent.Name, len(records)))
+	if len(records) == 0 {
+		return bf.String(), nil
+	}
+
+	var (
+		dealt, wins, draws, losses int
+		net                        int64
+		types                      = map[string]map[model.ResultType]int{}
+	)
+	for _, r := range records {
+		mine, theirs, ok := headToHeadHands(r, p.ID, opponent.ID)
+		if !ok {
+			continue
+		}
+		// money moved between the two is the reward of whoever bet
+		reward := mine.Reward
+		if mine.IsDealer {
+			dealt++
+			reward = -theirs.Reward
+		}
+		net += reward
+		switch {
+		case reward > 0:
+			wins++
+		case reward < 0:
+			losses++
+		default:
+			draws++
+		}
+		for id, rp := range map[string]model.GameRecordPlayer{p.ID: mine, opponent.ID: theirs} {
+			if types[id] == nil {
+				types[id] = map[model.ResultType]int{}
+			}
+			types[id][rp.ResultType]++
+		}
+	}
+
+	bf.WriteString(fmt.Sprintf("`%s` làm cái %d ván, `%s` làm cái %d ván\n", p.Name, dealt, opponent.Name, len(records)-dealt))
+	bf.WriteString(fmt.Sprintf("`%s` thắng %d, hoà %d, thua %d\n", p.Name, wins, draws, losses))
+	bf.WriteString(fmt.Sprintf("Tiền về `%s`: %s\n", p.Name, stringer.FormatCurrency(net)))
+
+	bf.WriteString("\nKết quả bài:\n")
+	for t := model.TypeDoubleBlackJack; t <= model.TypeTooLow; t++ {
+		if types[p.ID][t] == 0 && types[opponent.ID][t] == 0 {
+			continue
+		}
+		bf.WriteString(fmt.Sprintf("`%s`: `%s` %d, `%s` %d\n", resultTypeName(t), p.Name, types[p.ID][t], opponent.Name, types[opponent.ID][t]))
+	}
+
+	bf.WriteString("\nCác ván gần nhất:\n")
+	for i := len(records) - 1; i >= 0 && i >= len(records)-HeadToHeadEncounters; i-- {
+		r := records[i]
+		mine, theirs, ok := headToHeadHands(r, p.ID, opponent.ID)
+		if !ok {
+			continue
+		}
+		dealer, reward := opponent.Name, mine.Reward
+		if mine.IsDealer {
+			dealer, reward = p.Name, -theirs.Reward
+		}
+		bf.WriteString(fmt.Sprintf("`%s` `%s` làm cái: %s - %s, %s\n", r.EndedAt.Format("02/01 15:04"), dealer,
+			handName(mine), handName(theirs), stringer.FormatCurrency(reward)))
+	}
+	return bf.String(), nil
+}
+
+func headToHeadHands(r model.GameRecord, a, b string) (ha, hb model.GameRecordPlayer, ok bool) {
+	var foundA, foundB bool
+	for _, rp := range r.Participants {
+		switch rp.PlayerID {
+		case a:
+			ha, foundA = rp, true
+		case b:
+			hb, foundB = rp, true
+		}
+	}
+	return ha, hb, foundA && foundB
+}
+
+func resultTypeName(t model.ResultType) string {
+	if t == model.TypeNormal {
+		return "Bài thường"
+	}
+	return t.String()
+}
+
+func handName(rp model.GameRecordPlayer) string {
+	if rp.ResultType == model.TypeNormal {
+		return fmt.Sprintf("%d điểm", rp.Value)
+	}
+	return rp.ResultType.String()
+}
+
+func (m *Manager) Follow(ctx context.Context, p, followee *model.Player) error {
+	if p.ID == followee.ID {
+		return ErrPlayYourself
+	}
+	err := m.store.Follow(ctx, p.ID, followee.ID)
+	if errors.Is(err, model.ErrExists) {
+		return ErrAlreadyFollowing
+	}
+	return err
+}
+
+func (m *Manager) Unfollow(ctx context.Context, p, followee *model.Player) error {
+	err := m.store.Unfollow(ctx, p.ID, followee.ID)
+	if model.IsNotFound(err) {
+		return ErrNotFollowing
+	}
+	return err
+}
+
+// Following lists the players followed by p and whether they are dealing now
+func (m *Manager) Following(ctx context.Context, p *model.Player) (string, error) {
+	followees, err := m.store.ListFollowees(ctx, p.ID)
+	if err != nil {
+		return "", err
+	}
+	followers, err := m.store.ListFollowers(ctx, p.ID)
+	if err != nil {
+		return "", err
+	}
+
+	bf := bytes.NewBuffer(nil)
+	if len(followees) == 0 {
+		bf.WriteString("Bạn chưa theo dõi ai\n")
+	} else {
+		bf.WriteString(fmt.Sprintf("Bạn đang theo dõi %d người:\n", len(followees)))
+	}
+	for _, f := range followees {
+		name := f.FolloweeID
+		if fp := m.findPlayer(ctx, f.FolloweeID); fp != nil {
+			name = fp.Name
+		}
+		bf.WriteString(fmt.Sprintf("- `%s`", name))
+		if g := m.CurrentGame(f.FolloweeID); g != nil && g.Dealer().ID == f.FolloweeID {
+			bf.WriteString(fmt.Sprintf(" (đang làm cái ở phòng %s)", g.RoomID()))
+		}
+		bf.WriteString("\n")
+	}
+	bf.WriteString(fmt.Sprintf("\nCó %d người theo dõi bạn\n", len(followers)))
+	return bf.String(), nil
+}
+
+// Followers returns the players following the player, whatever their status
+func (m *Manager) Followers(ctx context.Context, id string) []model.Player {
+	fs, err := m.store.ListFollowers(ctx, id)
+	if err != nil {
+		log.Ctx(ctx).Err(err).Str("id", id).Msg("list followers failed")
+		return nil
+	}
+	var ps []model.Player
+	for _, f := range fs {
+		if p := m.findPlayer(ctx, f.FollowerID); p != nil {
+			ps = append(ps, *p)
+		}
+	}
+	return ps
+}
+
+func (m *Manager) ListPlayers(ctx context.Context) string {
+	players, err := m.store.ListPlayers(ctx)
+	if err != nil {
+		return err.Error()
+	}
+	res, _ := json.Marshal(players)
+	return string(res)
 }
 
 func (m *Manager) FindPlayerInGame(gameID string, playerID string) (*Game, *PlayerInGame) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	g := m.currentGame
-	if g == nil || g.ID() != gameID {
+	g := m.findGame(gameID)
+	if g == nil {
 		return nil, nil
 	}
 	return g, g.FindPlayer(playerID)
 }
 
-func (m *Manager) CurrentGame() *Game {
+// CurrentGame returns the game of the room which the player joined
+func (m *Manager) CurrentGame(playerID string) *Game {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	return m.currentGame
+	r := m.players[playerID]
+	if r == nil {
+		return nil
+	}
+	return r.Game()
 }
 
-func (m *Manager) PlayerLeave(ctx context.Context, id string) error {
+// isRunning reports whether the game is still in one of the rooms
+func (m *Manager) isRunning(gameID string) bool {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
+	return m.findGame(gameID) != nil
+}
+
+func (m *Manager) findGame(gameID string) *Game {
+	for _, r := range m.rooms {
+		if g := r.Game(); g != nil && g.ID() == gameID {
+			return g
+		}
+	}
+	return nil
+}
+
+func (m *Manager) PlayerLeave(ctx context.Context, id string) error {
+	p, f, err := m.playerLeave(ctx, id)
+	if err != nil {
+		return err
+	}
+	// the callback messages every member, so it runs outside the lock
+	if f != nil && p != nil {
+		f(p)
+	}
+	return nil
+}
+
+func (m *Manager) playerLeave(ctx context.Context, id string) (*model.Player, OnPlayerLeaveFunc, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 
 	if p, _ := m.store.GetPlayerByID(ctx, id); p == nil {
-		return ErrPlayerNotFound
+		return nil, nil, ErrPlayerNotFound
 	} else if !p.IsActive() {
-		return nil
+		return nil, nil, nil
 	}
 
-	if m.currentGame != nil {
-		if _, pg := m.FindPlayerInGame(m.currentGame.ID(), id); pg != nil {
-			return ErrYouAlreadyInGame
+	if r := m.players[id]; r != nil {
+		if g := r.Game(); g != nil && g.FindPlayer(id) != nil {
+			return nil, nil, ErrYouAlreadyInGame
 		}
+		m.leaveRoom(ctx, r, id)
 	}
 
 	p, err := m.store.UpdatePlayerStatus(ctx, id, model.UserStatusInactive)
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+	return p, m.onPlayerLeaveFunc, nil
+}
+
+func (m *Manager) CreateRoom(ctx context.Context, p *model.Player) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.players[p.ID] != nil {
+		return nil, ErrYouAlreadyInAnotherRoom
 	}
 
-	if m.onPlayerLeaveFunc != nil && p != nil {
-		m.onPlayerLeaveFunc(p)
+	id := ""
+	for i := 0; i < 100; i++ {
+		if tmp := generateRoomID(); m.rooms[tmp] == nil {
+			id = tmp
+			break
+		}
+	}
+	if len(id) == 0 {
+		return nil, ErrCannotCreateRoom
+	}
+
+	r := NewRoom(id, p.ID, findRule(DefaultRuleID), m.shoeCfg, m.maxBet.Load(), m.timeout.Load())
+	r.SetBettingWindow(m.window.Load())
+	m.rooms[id] = r
+	r.addMember(p.ID)
+	m.players[p.ID] = r
+	m.saveRoom(ctx, r)
+	log.Ctx(ctx).Debug().Str("room_id", id).Str("player_id", p.ID).Msg("room created")
+	return r, nil
+}
+
+func (m *Manager) JoinRoom(ctx context.Context, p *model.Player, roomID string) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := m.rooms[roomID]
+	if r == nil {
+		return nil, ErrRoomNotFound
+	}
+	if cur := m.players[p.ID]; cur == r {
+		return nil, ErrYouAlreadyInRoom
+	} else if cur != nil {
+		return nil, ErrYouAlreadyInAnotherRoom
+	}
+
+	r.addMember(p.ID)
+	m.players[p.ID] = r
+	m.saveRoom(ctx, r)
+	log.Ctx(ctx).Debug().Str("room_id", roomID).Str("player_id", p.ID).Msg("player joined room")
+	return r, nil
+}
+
+func (m *Manager) LeaveRoom(ctx context.Context, p *model.Player) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := m.players[p.ID]
+	if r == nil {
+		return nil, ErrNotInRoom
+	}
+	if g := r.Game(); g != nil && g.FindPlayer(p.ID) != nil {
+		return nil, ErrYouAlreadyInGame
+	}
+
+	m.leaveRoom(ctx, r, p.ID)
+	log.Ctx(ctx).Debug().Str("room_id", r.ID()).Str("player_id", p.ID).Msg("player left room")
+	return r, nil
+}
+
+// CloseRoom closes the room which p joined, only the room creator or admins can close it
+func (m *Manager) CloseRoom(ctx context.Context, p *model.Player) (*Room, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r := m.players[p.ID]
+	if r == nil {
+		return nil, ErrNotInRoom
+	}
+	if r.Creator() != p.ID && !p.IsAdmin() {
+		return nil, ErrNotRoomOwner
+	}
+	if r.Game() != nil {
+		return nil, ErrGameIsExisted
+	}
+
+	for _, id := range r.Members() {
+		delete(m.players, id)
+	}
+	delete(m.rooms, r.ID())
+	m.deleteRoom(ctx, r.ID())
+	log.Ctx(ctx).Debug().Str("room_id", r.ID()).Str("player_id", p.ID).Msg("room closed")
+	return r, nil
+}
+
+// UpdateRoom applies update to the room which p joined, only the room creator or admins can update it
+func (m *Manager) UpdateRoom(ctx context.Context, p *model.Player, update func(r *Room) error) (*Room, error) {
+	m.mu.RLock()
+	r := m.players[p.ID]
+	m.mu.RUnlock()
+
+	if r == nil {
+		return nil, ErrNotInRoom
+	}
+	if r.Creator() != p.ID && !p.IsAdmin() {
+		return nil, ErrNotRoomOwner
+	}
+	if err := update(r); err != nil {
+		return nil, err
+	}
+	m.saveRoom(ctx, r)
+	return r, nil
+}
+
+func (m *Manager) Rooms() []*Room {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rooms := make([]*Room, 0, len(m.rooms))
+	for _, r := range m.rooms {
+		rooms = append(rooms, r)
+	}
+	sort.Slice(rooms, func(i, j int) bool {
+		return rooms[i].ID() < rooms[j].ID()
+	})
+	return rooms
+}
+
+func (m *Manager) Room(roomID string) *Room {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.rooms[roomID]
+}
+
+func (m *Manager) PlayerRoom(playerID string) *Room {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.players[playerID]
+}
+
+// RoomPlayers returns active members of the room
+func (m *Manager) RoomPlayers(ctx context.Context, roomID string) []model.Player {
+	r := m.Room(roomID)
+	if r == nil {
+		return nil
 	}
 
+	var ps []model.Player
+	for _, id := range r.Members() {
+		if p := m.findPlayer(ctx, id); p != nil && p.IsActive() {
+			ps = append(ps, *p)
+		}
+	}
+	return ps
+}
+
+func (m *Manager) RoomInfo(ctx context.Context, r *Room) string {
+	bf := bytes.NewBuffer(nil)
+	bf.WriteString(fmt.Sprintf("Phòng `%s`\n", r.ID()))
+	bf.WriteString(fmt.Sprintf("- Cược tối đa: %s\n", stringer.FormatCurrency(r.MaxBet())))
+	bf.WriteString(fmt.Sprintf("- Thời gian chờ: %s\n", r.Timeout()))
+	if w := r.BettingWindow(); w > 0 {
+		bf.WriteString(fmt.Sprintf("- Tự động chia bài sau: %s\n", w))
+	}
+	bf.WriteString(fmt.Sprintf("- Rule: %s\n", r.Rule().Name))
+	if r.Game() != nil {
+		bf.WriteString("- Đang có ván diễn ra\n")
+	}
+
+	ids := r.Members()
+	bf.WriteString(fmt.Sprintf("Thành viên (%d):", len(ids)))
+	for _, id := range ids {
+		p := m.findPlayer(ctx, id)
+		if p == nil {
+			continue
+		}
+		bf.WriteString(fmt.Sprintf("\n  - `%s`: %s", p.Name, stringer.FormatCurrency(p.Balance)))
+		if id == r.Creator() {
+			bf.WriteString(" (chủ phòng)")
+		}
+	}
+	return bf.String()
+}
+
+// leaveRoom removes the player from r and drops r if nobody is left, m.mu must be held
+func (m *Manager) leaveRoom(ctx context.Context, r *Room, playerID string) {
+	r.removeMember(playerID)
+	delete(m.players, playerID)
+	if r.isEmpty() && r.Game() == nil {
+		delete(m.rooms, r.ID())
+		m.deleteRoom(ctx, r.ID())
+		return
+	}
+	m.saveRoom(ctx, r)
+}
+
+// LoadRules loads rules from the file at path (if any) and the ones saved by admins, the saved ones take precedence
+func (m *Manager) LoadRules(ctx context.Context, path string) error {
+	var rules []Rule
+	if len(path) > 0 {
+		rs, err := LoadRulesFile(path)
+		if err != nil {
+			return fmt.Errorf("load rules from %s: %w", path, err)
+		}
+		rules = append(rules, rs...)
+	}
+
+	configs, err := m.store.ListRules(ctx)
+	if err != nil {
+		return err
+	}
+	for _, c := range configs {
+		rs, err := ParseRules(c.Data)
+		if err != nil {
+			return fmt.Errorf("load rule %s: %w", c.ID, err)
+		}
+		rules = append(rules, rs...)
+	}
+
+	if err := Rules.Reset(rules...); err != nil {
+		return err
+	}
+	m.mu.Lock()
+	m.rulesFile = path
+	m.mu.Unlock()
+	log.Ctx(ctx).Info().Strs("rules", Rules.SortedIDs()).Msg("loaded rules")
 	return nil
 }
+
+// ReloadRules loads rules again from the last used file and storage
+func (m *Manager) ReloadRules(ctx context.Context) error {
+	m.mu.RLock()
+	path := m.rulesFile
+	m.mu.RUnlock()
+	return m.LoadRules(ctx, path)
+}
+
+// SaveRules parses rules in JSON then adds or replaces them at runtime
+func (m *Manager) SaveRules(ctx context.Context, operator *model.Player, data []byte) ([]Rule, error) {
+	rules, err := ParseRules(data)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, r := range rules {
+		bs, err := json.Marshal(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := m.store.SaveRule(ctx, &model.RuleConfig{
+			ID:        r.ID,
+			Data:      bs,
+			UpdatedBy: operator.ID,
+			UpdatedAt: time.Now(),
+		}); err != nil {
+			return nil, err
+		}
+	}
+	if err := Rules.Set(rules...); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (m *Manager) DeleteRule(ctx context.Context, id string) error {
+	if err := Rules.Delete(id); err != nil {
+		return err
+	}
+	return m.store.DeleteRule(ctx, id)
+}
+
+// Restore rebuilds rooms and their running games from storage, it returns the restored games
+func (m *Manager) Restore(ctx context.Context) ([]*Game, error) {
+	states, err := m.store.ListRooms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	findPlayer := func(id string) *model.Player {
+		return m.findPlayer(ctx, id)
+	}
+
+	var games []*Game
+	m.mu.Lock()
+	for i := range states {
+		st := &states[i]
+		r := restoreRoom(st, m.shoeCfg)
+		if st.Game != nil {
+			g := restoreGame(r.ID(), st.Game, m.shoeCfg.Shuffler, findPlayer)
+			if r.shoe != nil {
+				// the game was dealt from the room's shoe
+				g.shoe = r.shoe
+			}
+			m.watchGame(g)
+			r.setGame(g)
+			games = append(games, g)
+		}
+		m.rooms[r.ID()] = r
+		for _, id := range r.Members() {
+			m.players[id] = r
+		}
+	}
+	m.mu.Unlock()
+
+	for _, g := range games {
+		m.resumeTimers(g)
+	}
+
+	log.Ctx(ctx).Info().Int("rooms", len(states)).Int("games", len(games)).Msg("restored rooms")
+	return games, nil
+}
+
+// resumeTimers restarts the turn timer of the game and its betting window
+func (m *Manager) resumeTimers(g *Game) {
+	if pg := g.CurrentPlaying(); pg != nil && !g.Finished() && pg.Status() == PlayerPlaying {
+		m.scheduleTurn(g, pg, time.Unix(pg.LastHit(), 0))
+	}
+	// the betting window starts over
+	if r := m.Room(g.RoomID()); r != nil && r.BettingWindow() > 0 && g.Status() == Betting {
+		g.setDealAt(time.Now().Add(r.BettingWindow()))
+		m.startCountdown(g)
+	}
+}
+
+// Snapshot saves all rooms and their running games to storage
+func (m *Manager) Snapshot(ctx context.Context) {
+	for _, r := range m.Rooms() {
+		m.saveRoom(ctx, r)
+	}
+}
+
+// ReplayGame renders a game step by step from its saved events
+func (m *Manager) ReplayGame(ctx context.Context, gameID string) (string, error) {
+	// the events of a running game would show the cards that are still hidden
+	if m.isRunning(gameID) {
+		return "", ErrGameNotFinished
+	}
+	events, err := m.store.ListGameEvents(ctx, gameID)
+	if err != nil {
+		return "", err
+	}
+	if len(events) == 0 {
+		return "", ErrGameNotFound
+	}
+	res, err := ReplayText(events, func(id string) *model.Player {
+		return m.findPlayer(ctx, id)
+	})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Diễn biến ván `%s`:\n\n%s", gameID, res), nil
+}
+
+// saveProof saves the revealed seeds of a fair game so it can be verified later
+func (m *Manager) saveProof(ctx context.Context, g *Game) {
+	proof := g.Proof()
+	if proof == nil {
+		return
+	}
+	if err := m.store.SaveGameProof(ctx, proof); err != nil {
+		log.Ctx(ctx).Err(err).Str("game_id", proof.GameID).Msg("save game proof failed")
+	}
+}
+
+func (m *Manager) saveGame(ctx context.Context, g *Game) {
+	if r := m.Room(g.RoomID()); r != nil {
+		m.saveRoom(ctx, r)
+	}
+}
+
+func (m *Manager) saveRoom(ctx context.Context, r *Room) {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	if err := m.store.SaveRoom(ctx, r.snapshot()); err != nil {
+		log.Ctx(ctx).Err(err).Str("room_id", r.ID()).Msg("save room failed")
+	}
+	if g := r.Game(); g != nil {
+		m.saveEventsLocked(ctx, g)
+	}
+}
+
+func (m *Manager) saveEvents(ctx context.Context, g *Game) {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+	m.saveEventsLocked(ctx, g)
+}
+
+func (m *Manager) saveEventsLocked(ctx context.Context, g *Game) {
+	evs := g.takeEvents()
+	if len(evs) == 0 {
+		return
+	}
+	if err := m.store.SaveGameEvents(ctx, evs); err != nil {
+		log.Ctx(ctx).Err(err).Str("game_id", g.ID()).Msg("save game events failed")
+	}
+}
+
+func (m *Manager) deleteRoom(ctx context.Context, id string) {
+	m.saveMu.Lock()
+	defer m.saveMu.Unlock()
+
+	if err := m.store.DeleteRoom(ctx, id); err != nil {
+		log.Ctx(ctx).Err(err).Str("room_id", id).Msg("delete room failed")
+	}
+}
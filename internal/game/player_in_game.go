@@ -18,6 +18,7 @@ const (
 
 type PlayerInGame struct {
 	*model.Player
+	rule      *Rule
 	cards     Cards
 	betAmount atomic.Uint64
 	isDealer  atomic.Bool
@@ -50,9 +51,10 @@ const (
 	PlayerDone
 )
 
-func NewPlayerInGame(player *model.Player, betAmount int64, isDealer bool) *PlayerInGame {
+func NewPlayerInGame(player *model.Player, rule *Rule, betAmount int64, isDealer bool) *PlayerInGame {
 	return &PlayerInGame{
 		Player:    player,
+		rule:      rule,
 		betAmount: *atomic.NewUint64(uint64(betAmount)),
 		isDealer:  *atomic.NewBool(isDealer),
 	}
@@ -91,7 +93,25 @@ func (p *PlayerInGame) CardsString() string {
 	} else {
 		censor = PlayerInGameStatus(p.status.Load()) != PlayerDone
 	}
-	return p.cards.String(censor, p.isDealer.Load())
+	return p.cards.String(p.rule, censor, p.isDealer.Load())
+}
+
+// CardsInfo shows all cards with their type and value
+func (p *PlayerInGame) CardsInfo() string {
+	return p.Cards().String(p.rule, false, p.isDealer.Load())
+}
+
+func (p *PlayerInGame) Value() int {
+	return p.Cards().Value(p.rule)
+}
+
+// StartValue is the value of the first two cards, 0 before the deal
+func (p *PlayerInGame) StartValue() int {
+	cards := p.Cards()
+	if len(cards) < 2 {
+		return 0
+	}
+	return cards[:2].Value(p.rule)
 }
 
 func (p *PlayerInGame) AddCard(card Card) {
@@ -113,10 +133,12 @@ func (p *PlayerInGame) Stand() error {
 	if PlayerInGameStatus(p.status.Load()) != PlayerPlaying {
 		return ErrYouNotPlaying
 	}
-	if p.cards.Type(p.isDealer.Load()) == model.TypeTooLow {
+	if p.cards.Type(p.rule, p.isDealer.Load()) == model.TypeTooLow {
 		return ErrTooLow
 	}
-	p.status.Store(uint32(PlayerStood))
+	if !p.status.CAS(uint32(PlayerPlaying), uint32(PlayerStood)) {
+		return ErrYouNotPlaying
+	}
 	return nil
 }
 
@@ -138,17 +160,17 @@ func (p *PlayerInGame) Reward() int64 {
 }
 
 func (p *PlayerInGame) CanHit() bool {
-	t := p.Cards().Type(p.isDealer.Load())
-	return t == model.TypeTooLow || (t == model.TypeNormal && p.Cards().Value() < 21)
+	t := p.ResultType()
+	return t == model.TypeTooLow || (t == model.TypeNormal && p.Value() < 21)
 }
 
 func (p *PlayerInGame) CanStand() bool {
-	t := p.Cards().Type(p.isDealer.Load())
+	t := p.ResultType()
 	return t != model.TypeTooLow
 }
 
 func (p *PlayerInGame) ResultType() model.ResultType {
-	return p.Cards().Type(p.isDealer.Load())
+	return p.Cards().Type(p.rule, p.isDealer.Load())
 }
 
 func (p *PlayerInGame) Type() PlayerType {
@@ -0,0 +1,148 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// dealerTurn plays a game of four participants who hit up to 17, until it is the dealer's turn
+func dealerTurn(t *testing.T, m *Manager) *Game {
+	t.Helper()
+	ctx := context.Background()
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	g, _ := m.NewGame(dealer, "")
+	for _, id := range []string{"2", "3", "4", "5"} {
+		p := &model.Player{ID: id, Name: "Player #" + id, Balance: 1000}
+		_, _ = m.JoinRoom(ctx, p, r.ID())
+		if err := m.PlayerBet(ctx, g.ID(), p, 10); err != nil {
+			t.Fatalf("PlayerBet() error = %v", err)
+		}
+	}
+	if _, err := m.Deal(ctx, g.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+	if err := m.Start(ctx, g); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// participants hit up to 17, then it is the dealer's turn
+	for pg := g.CurrentPlaying(); pg != nil && !pg.IsDealer(); pg = g.CurrentPlaying() {
+		for pg.CanHit() && pg.Value() < 17 {
+			if err := m.PlayerHit(ctx, g, pg); err != nil {
+				t.Fatalf("PlayerHit() error = %v", err)
+			}
+		}
+		if err := m.PlayerStand(ctx, g, pg); err != nil {
+			t.Fatalf("PlayerStand() error = %v", err)
+		}
+	}
+	if g.Finished() {
+		t.Fatalf("game finished before the dealer's turn, pick another seed")
+	}
+	return g
+}
+
+func TestManager_RevealAll(t *testing.T) {
+	ctx := context.Background()
+	// the room's shoe is not shuffled by the game id, so the cards only depend on the seed
+	m := NewManager(newFakeStore(), 100, 0, time.Minute, ShoeConfig{Decks: 1, Persist: true, Shuffler: NewSeededShuffler(3)})
+	g := dealerTurn(t, m)
+
+	var many, rest int
+	for _, pg := range g.PlayersInGame() {
+		if !g.CanReveal(pg) {
+			continue
+		}
+		if len(pg.Cards()) >= 3 {
+			many++
+		} else {
+			rest++
+		}
+	}
+	if many == 0 || rest == 0 {
+		t.Fatalf("want hands with both few and many cards, pick another seed")
+	}
+	tests := []struct {
+		name     string
+		minCards int
+		want     int
+		wantErr  error
+	}{
+		{name: "at least 3 cards", minCards: 3, want: many},
+		{name: "everyone left", minCards: 0, want: rest},
+		{name: "nobody left", minCards: 0, wantErr: ErrNoPlayerToReveal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reveals, err := m.RevealAll(ctx, g, tt.minCards)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RevealAll() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(reveals) != tt.want {
+				t.Errorf("RevealAll() revealed %d players, want %d", len(reveals), tt.want)
+			}
+			for _, rv := range reveals {
+				if !rv.Player.IsDone() || len(rv.Player.Cards()) < tt.minCards {
+					t.Errorf("%s revealed with %d cards", rv.Player.Name, len(rv.Player.Cards()))
+				}
+			}
+		})
+	}
+	if !g.Finished() {
+		t.Errorf("game is not finished after revealing everyone")
+	}
+}
+
+func TestManager_FinishGameRevealLimits(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newFakeStore(), 100, 0, time.Minute, ShoeConfig{Decks: 1, Persist: true, Shuffler: NewSeededShuffler(3)})
+	g := dealerTurn(t, m)
+	rule := *g.rule
+	rule.RevealLimits = []RevealLimit{{MinCards: 10}}
+	g.rule = &rule
+
+	if g.CanFinish() {
+		t.Errorf("CanFinish() = true, want false")
+	}
+	if err := m.FinishGame(ctx, g, false); !errors.Is(err, ErrRevealNotAllowed) {
+		t.Fatalf("FinishGame() error = %v, want %v", err, ErrRevealNotAllowed)
+	}
+	for _, pg := range g.PlayersInGame() {
+		if pg.IsDone() {
+			t.Errorf("%s was settled by a refused finish", pg.Name)
+		}
+	}
+	// the turn timer still ends the game
+	if err := m.FinishGame(ctx, g, true); err != nil {
+		t.Fatalf("FinishGame() force error = %v", err)
+	}
+}
+
+func TestManager_PassRevealLimits(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Persist: true, Shuffler: NewSeededShuffler(3)})
+	g := dealerTurn(t, m)
+	rule := *g.rule
+	rule.RevealLimits = []RevealLimit{{MinCards: 10}}
+	g.rule = &rule
+
+	// passing ends the game whatever the limits
+	g.Dealer().SetLastHit(time.Now().Add(-time.Hour).Unix())
+	if _, err := m.PlayerPass(ctx, &model.Player{ID: g.Dealer().ID}); err != nil {
+		t.Fatalf("PlayerPass() error = %v", err)
+	}
+	if _, ok := store.records[g.ID()]; !ok || m.Room(g.RoomID()).Game() != nil {
+		t.Errorf("game was not settled after the dealer passed")
+	}
+	for _, pg := range g.PlayersInGame() {
+		if !pg.IsDone() {
+			t.Errorf("%s was not settled", pg.Name)
+		}
+	}
+}
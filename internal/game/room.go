@@ -0,0 +1,163 @@
+package game
+
+import (
+	"sync"
+	"time"
+
+	"go.uber.org/atomic"
+)
+
+type Room struct {
+	id      string
+	creator string
+	maxBet  atomic.Uint64
+	timeout atomic.Duration
+	window  atomic.Duration // betting window, zero to deal by hand
+	rule    *Rule
+	members []string
+	game    *Game
+	shoeCfg ShoeConfig
+	shoe    *Shoe
+
+	mu sync.RWMutex
+}
+
+func NewRoom(id string, creator string, rule *Rule, shoeCfg ShoeConfig, maxBet uint64, timeout time.Duration) *Room {
+	return &Room{
+		id:      id,
+		creator: creator,
+		rule:    rule,
+		shoeCfg: shoeCfg,
+		maxBet:  *atomic.NewUint64(maxBet),
+		timeout: *atomic.NewDuration(timeout),
+	}
+}
+
+func (r *Room) ID() string {
+	return r.id
+}
+
+func (r *Room) Creator() string {
+	return r.creator
+}
+
+func (r *Room) MaxBet() uint64 {
+	return r.maxBet.Load()
+}
+
+func (r *Room) SetMaxBet(maxBet uint64) {
+	r.maxBet.Store(maxBet)
+}
+
+func (r *Room) Timeout() time.Duration {
+	return r.timeout.Load()
+}
+
+func (r *Room) SetTimeout(timeout time.Duration) {
+	r.timeout.Store(timeout)
+}
+
+// BettingWindow is how long a new game waits for bets before it is dealt automatically
+func (r *Room) BettingWindow() time.Duration {
+	return r.window.Load()
+}
+
+func (r *Room) SetBettingWindow(window time.Duration) {
+	r.window.Store(window)
+}
+
+func (r *Room) Rule() *Rule {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.rule
+}
+
+func (r *Room) SetRule(rule *Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rule = rule
+}
+
+// Members returns a copy of the member IDs, in join order
+func (r *Room) Members() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	res := make([]string, len(r.members))
+	copy(res, r.members)
+	return res
+}
+
+func (r *Room) HasMember(id string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.hasMember(id)
+}
+
+func (r *Room) Game() *Game {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.game
+}
+
+// nextShoe returns the shoe for a new game, the same one is reused when the room keeps its shoe
+func (r *Room) nextShoe() *Shoe {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.shoeCfg.Persist {
+		return newShoe(r.shoeCfg)
+	}
+	if r.shoe == nil {
+		r.shoe = newShoe(r.shoeCfg)
+		r.shoe.shared = true
+	}
+	return r.shoe
+}
+
+func (r *Room) hasMember(id string) bool {
+	for _, m := range r.members {
+		if m == id {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *Room) addMember(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.hasMember(id) {
+		r.members = append(r.members, id)
+	}
+}
+
+func (r *Room) removeMember(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.members {
+		if r.members[i] == id {
+			r.members = append(r.members[:i], r.members[i+1:]...)
+			return
+		}
+	}
+}
+
+func (r *Room) setGame(g *Game) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.game = g
+}
+
+// clearGame removes g from the room, it does nothing if another game is running
+func (r *Room) clearGame(g *Game) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.game == g {
+		r.game = nil
+	}
+}
+
+func (r *Room) isEmpty() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return len(r.members) == 0
+}
@@ -0,0 +1,529 @@
+package game
+
+import (
+	"context"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_Rooms(t *testing.T) {
+	ctx := context.Background()
+	m := NewManager(newFakeStore(), 100, 0, time.Minute, DefaultShoeConfig)
+	p1 := &model.Player{ID: "1"}
+	p2 := &model.Player{ID: "2"}
+
+	r, err := m.CreateRoom(ctx, p1)
+	if err != nil {
+		t.Fatalf("CreateRoom() error = %v", err)
+	}
+	if _, err := m.CreateRoom(ctx, p1); err != ErrYouAlreadyInAnotherRoom {
+		t.Errorf("CreateRoom() error = %v, want %v", err, ErrYouAlreadyInAnotherRoom)
+	}
+	if _, err := m.JoinRoom(ctx, p2, "xx"); err != ErrRoomNotFound {
+		t.Errorf("JoinRoom() error = %v, want %v", err, ErrRoomNotFound)
+	}
+	if _, err := m.JoinRoom(ctx, p2, r.ID()); err != nil {
+		t.Fatalf("JoinRoom() error = %v", err)
+	}
+	if _, err := m.JoinRoom(ctx, p2, r.ID()); err != ErrYouAlreadyInRoom {
+		t.Errorf("JoinRoom() error = %v, want %v", err, ErrYouAlreadyInRoom)
+	}
+	if _, err := m.CloseRoom(ctx, p2); err != ErrNotRoomOwner {
+		t.Errorf("CloseRoom() error = %v, want %v", err, ErrNotRoomOwner)
+	}
+
+	g, err := m.NewGame(p1, "")
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	if g.RoomID() != r.ID() || m.CurrentGame(p2.ID) != g {
+		t.Errorf("CurrentGame() = %v, want %v", m.CurrentGame(p2.ID), g)
+	}
+	if _, err := m.CloseRoom(ctx, p1); err != ErrGameIsExisted {
+		t.Errorf("CloseRoom() error = %v, want %v", err, ErrGameIsExisted)
+	}
+	if _, err := m.CancelGame(ctx, g.ID()); err != nil {
+		t.Fatalf("CancelGame() error = %v", err)
+	}
+	// a late cancel of the old game must not cancel the next one
+	g2, err := m.NewGame(p1, "")
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	if _, err := m.CancelGame(ctx, g.ID()); err != ErrGameNotFound {
+		t.Errorf("CancelGame() error = %v, want %v", err, ErrGameNotFound)
+	}
+	if m.CurrentGame(p2.ID) != g2 {
+		t.Errorf("CurrentGame() = %v, want %v", m.CurrentGame(p2.ID), g2)
+	}
+	if _, err := m.CancelGame(ctx, g2.ID()); err != nil {
+		t.Fatalf("CancelGame() error = %v", err)
+	}
+
+	if _, err := m.LeaveRoom(ctx, p2); err != nil {
+		t.Fatalf("LeaveRoom() error = %v", err)
+	}
+	if _, err := m.LeaveRoom(ctx, p2); err != ErrNotInRoom {
+		t.Errorf("LeaveRoom() error = %v, want %v", err, ErrNotInRoom)
+	}
+	if _, err := m.LeaveRoom(ctx, p1); err != nil {
+		t.Fatalf("LeaveRoom() error = %v", err)
+	}
+	if len(m.Rooms()) != 0 {
+		t.Errorf("Rooms() = %v, want empty", m.Rooms())
+	}
+}
+
+// fakeStore keeps rooms, games and their proofs and events in memory, other Storage methods are not implemented.
+// Every player exists and starts with a balance of 1000.
+type fakeStore struct {
+	Storage
+	rooms    map[string]model.RoomState
+	proofs   map[string]model.GameProof
+	events   map[string][]model.GameEvent
+	records  map[string]model.GameRecord
+	results  []model.Record
+	follows  []model.Following
+	seasons  []model.Season // closed seasons
+	loans    []model.Loan
+	faucets  map[string]model.Faucet
+	escrows  map[string]model.Escrow
+	balances map[string]int64 // changes from the initial balance
+	ledger   []model.LedgerEntry
+
+	settleErr error // returned by SettleGame when set
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		rooms:    map[string]model.RoomState{},
+		proofs:   map[string]model.GameProof{},
+		events:   map[string][]model.GameEvent{},
+		records:  map[string]model.GameRecord{},
+		escrows:  map[string]model.Escrow{},
+		balances: map[string]int64{},
+		faucets:  map[string]model.Faucet{},
+	}
+}
+
+func (s *fakeStore) SaveRoom(ctx context.Context, r *model.RoomState) error {
+	s.rooms[r.ID] = *r
+	return nil
+}
+
+func (s *fakeStore) DeleteRoom(ctx context.Context, id string) error {
+	delete(s.rooms, id)
+	return nil
+}
+
+func (s *fakeStore) ListRooms(ctx context.Context) ([]model.RoomState, error) {
+	var rooms []model.RoomState
+	for _, r := range s.rooms {
+		rooms = append(rooms, r)
+	}
+	return rooms, nil
+}
+
+func (s *fakeStore) GetPlayerByID(ctx context.Context, id string) (*model.Player, error) {
+	return &model.Player{ID: id, TelegramID: id, Name: "Player #" + id, Balance: 1000 + s.balances[id]}, nil
+}
+
+func (s *fakeStore) SaveGameProof(ctx context.Context, p *model.GameProof) error {
+	s.proofs[p.GameID] = *p
+	return nil
+}
+
+func (s *fakeStore) GetGameProof(ctx context.Context, gameID string) (*model.GameProof, error) {
+	p, ok := s.proofs[gameID]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return &p, nil
+}
+
+func (s *fakeStore) SaveGameEvents(ctx context.Context, events []model.GameEvent) error {
+	for _, ev := range events {
+		s.events[ev.GameID] = append(s.events[ev.GameID], ev)
+	}
+	return nil
+}
+
+func (s *fakeStore) ListGameEvents(ctx context.Context, gameID string) ([]model.GameEvent, error) {
+	return s.events[gameID], nil
+}
+
+func (s *fakeStore) SaveRecord(ctx context.Context, r *model.Record) error {
+	r.ID = uint64(len(s.results) + 1)
+	s.results = append(s.results, *r)
+	return nil
+}
+
+func (s *fakeStore) AggregateRecords(ctx context.Context, playerID string, from, to time.Time, role model.StatsRole) (*model.PlayerStats, error) {
+	stats := model.NewPlayerStats()
+	for i := range s.results {
+		r := &s.results[i]
+		if r.PlayerID == playerID && role.Match(r) && !r.CreatedAt.Before(from) && (to.IsZero() || r.CreatedAt.Before(to)) {
+			stats.Add(r)
+		}
+	}
+	return stats, nil
+}
+
+func (s *fakeStore) ResetBalance(ctx context.Context, newBalance int64, operatorID string) (*model.Season, error) {
+	for _, e := range s.escrows {
+		if !e.Settled {
+			return nil, model.ErrUnsettledGames
+		}
+	}
+	cur, _ := s.CurrentSeason(ctx)
+	players, _ := s.ListPlayers(ctx)
+	sort.SliceStable(players, func(i, j int) bool {
+		return players[i].Balance > players[j].Balance
+	})
+	for i, p := range players {
+		cur.Standings = append(cur.Standings, model.SeasonStanding{Rank: i + 1, PlayerID: p.ID, Name: p.Name, Balance: p.Balance})
+		s.balances[p.ID] = newBalance - 1000
+	}
+	cur.EndedAt, cur.Closed, cur.ClosedBy = time.Now(), true, operatorID
+	s.seasons = append(s.seasons, *cur)
+	return cur, nil
+}
+
+func (s *fakeStore) CurrentSeason(ctx context.Context) (*model.Season, error) {
+	if len(s.seasons) == 0 {
+		return &model.Season{Number: 1}, nil
+	}
+	last := s.seasons[len(s.seasons)-1]
+	return &model.Season{Number: last.Number + 1, StartedAt: last.EndedAt}, nil
+}
+
+func (s *fakeStore) GetSeason(ctx context.Context, number int) (*model.Season, error) {
+	if number < 1 || number > len(s.seasons) {
+		return nil, model.ErrNotFound
+	}
+	season := s.seasons[number-1]
+	return &season, nil
+}
+
+func (s *fakeStore) Follow(ctx context.Context, followerID, followeeID string) error {
+	for _, f := range s.follows {
+		if f.FollowerID == followerID && f.FolloweeID == followeeID {
+			return model.ErrExists
+		}
+	}
+	s.follows = append(s.follows, model.Following{ID: uint64(len(s.follows) + 1), FollowerID: followerID, FolloweeID: followeeID})
+	return nil
+}
+
+func (s *fakeStore) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	for i, f := range s.follows {
+		if f.FollowerID == followerID && f.FolloweeID == followeeID {
+			s.follows = append(s.follows[:i], s.follows[i+1:]...)
+			return nil
+		}
+	}
+	return model.ErrNotFound
+}
+
+func (s *fakeStore) ListFollowees(ctx context.Context, followerID string) ([]model.Following, error) {
+	var fs []model.Following
+	for _, f := range s.follows {
+		if f.FollowerID == followerID {
+			fs = append(fs, f)
+		}
+	}
+	return fs, nil
+}
+
+func (s *fakeStore) ListFollowers(ctx context.Context, followeeID string) ([]model.Following, error) {
+	var fs []model.Following
+	for _, f := range s.follows {
+		if f.FolloweeID == followeeID {
+			fs = append(fs, f)
+		}
+	}
+	return fs, nil
+}
+
+func (s *fakeStore) ListHeadToHeadRecords(ctx context.Context, a, b string) ([]model.GameRecord, error) {
+	var records []model.GameRecord
+	for _, r := range s.records {
+		if r.Status != model.GameFinished || r.DealerID != a && r.DealerID != b {
+			continue
+		}
+		joined := 0
+		for _, id := range r.PlayerIDs {
+			if id == a || id == b {
+				joined++
+			}
+		}
+		if joined == 2 {
+			records = append(records, r)
+		}
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].EndedAt.Before(records[j].EndedAt)
+	})
+	return records, nil
+}
+
+func (s *fakeStore) Leaderboard(ctx context.Context, from time.Time) ([]model.PlayerTotals, error) {
+	totals := map[string]*model.PlayerTotals{}
+	var ids []string
+	for i := range s.results {
+		r := &s.results[i]
+		if !from.IsZero() && model.TotalsDay(r.CreatedAt) < model.TotalsDay(from) {
+			continue
+		}
+		if totals[r.PlayerID] == nil {
+			totals[r.PlayerID] = &model.PlayerTotals{PlayerID: r.PlayerID}
+			ids = append(ids, r.PlayerID)
+		}
+		totals[r.PlayerID].Add(r)
+	}
+	sort.Strings(ids)
+	var res []model.PlayerTotals
+	for _, id := range ids {
+		res = append(res, *totals[id])
+	}
+	return res, nil
+}
+
+func (s *fakeStore) ListRecords(ctx context.Context, playerID string, from, to time.Time, cursor model.Cursor, limit int) ([]model.Record, error) {
+	var records []model.Record
+	for i := len(s.results) - 1; i >= 0; i-- {
+		r := s.results[i]
+		if r.PlayerID != playerID || r.CreatedAt.Before(from) || !to.IsZero() && !r.CreatedAt.Before(to) ||
+			cursor.Before > 0 && r.ID >= cursor.Before || r.ID <= cursor.After {
+			continue
+		}
+		records = append(records, r)
+	}
+	if len(records) > limit {
+		if cursor.After > 0 {
+			records = records[len(records)-limit:]
+		} else {
+			records = records[:limit]
+		}
+	}
+	return records, nil
+}
+
+func (s *fakeStore) SaveGameRecord(ctx context.Context, r *model.GameRecord) error {
+	s.records[r.ID] = *r
+	return nil
+}
+
+func (s *fakeStore) EscrowBets(ctx context.Context, gameID string, bets map[string]uint64) error {
+	if _, ok := s.escrows[gameID]; ok {
+		return nil
+	}
+	e := model.Escrow{GameID: gameID}
+	for id, amount := range bets {
+		if 1000+s.balances[id] < int64(amount) {
+			return model.ErrInsufficientBalance
+		}
+	}
+	for id, amount := range bets {
+		s.balances[id] -= int64(amount)
+		e.Holds = append(e.Holds, model.EscrowHold{PlayerID: id, Amount: amount})
+	}
+	s.escrows[gameID] = e
+	return nil
+}
+
+func (s *fakeStore) SettleGame(ctx context.Context, gameID string, rewards map[string]int64, records []model.Record, gr *model.GameRecord) ([]model.Repayment, error) {
+	if s.settleErr != nil {
+		return nil, s.settleErr
+	}
+	e := s.escrows[gameID]
+	if e.Settled {
+		return nil, model.ErrAlreadySettled
+	}
+	for _, h := range e.Holds {
+		s.balances[h.PlayerID] += int64(h.Amount)
+	}
+	for id, reward := range rewards {
+		s.balances[id] += reward
+	}
+	var ids []string
+	for id, reward := range rewards {
+		if reward > 0 {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	var repayments []model.Repayment
+	for _, id := range ids {
+		rs, _ := s.RepayLoans(ctx, id, "", rewards[id], true)
+		repayments = append(repayments, rs...)
+	}
+	for i := range records {
+		_ = s.SaveRecord(ctx, &records[i])
+	}
+	if gr != nil {
+		_ = s.SaveGameRecord(ctx, gr)
+	}
+	e.Settled = true
+	s.escrows[gameID] = e
+	return repayments, nil
+}
+
+func (s *fakeStore) Transfer(ctx context.Context, fromID, toID string, amount int64) (from, to *model.Player, err error) {
+	if 1000+s.balances[fromID] < amount {
+		return nil, nil, model.ErrInsufficientBalance
+	}
+	s.move(fromID, toID, amount)
+	f := s.faucets[fromID]
+	f.LastGaveAt = time.Now()
+	s.faucets[fromID] = f
+	from, _ = s.GetPlayerByID(ctx, fromID)
+	to, _ = s.GetPlayerByID(ctx, toID)
+	return from, to, nil
+}
+
+func (s *fakeStore) move(fromID, toID string, amount int64) {
+	s.balances[fromID] -= amount
+	s.balances[toID] += amount
+}
+
+func (s *fakeStore) CreateLoan(ctx context.Context, l *model.Loan) error {
+	if _, _, err := s.Transfer(ctx, l.LenderID, l.BorrowerID, l.Amount); err != nil {
+		return err
+	}
+	s.loans = append(s.loans, *l)
+	return nil
+}
+
+func (s *fakeStore) RepayLoans(ctx context.Context, borrowerID, lenderID string, amount int64, auto bool) ([]model.Repayment, error) {
+	if auto {
+		if balance := 1000 + s.balances[borrowerID]; amount == 0 || balance < amount {
+			amount = balance
+		}
+		if amount <= 0 {
+			return nil, nil
+		}
+	}
+	var repayments []model.Repayment
+	total := int64(0)
+	for _, l := range s.loans {
+		if l.Closed || l.BorrowerID != borrowerID || len(lenderID) > 0 && l.LenderID != lenderID || auto && !l.AutoRepay {
+			continue
+		}
+		pay := l.Outstanding()
+		if amount > 0 && pay > amount-total {
+			pay = amount - total
+		}
+		if pay <= 0 {
+			break
+		}
+		l.Repaid += pay
+		l.Closed = l.Outstanding() == 0
+		repayments = append(repayments, model.Repayment{Loan: l, Amount: pay})
+		total += pay
+	}
+	if 1000+s.balances[borrowerID] < total {
+		return nil, model.ErrInsufficientBalance
+	}
+	for _, r := range repayments {
+		// repaying is not giving money away, so the faucet state is kept
+		s.move(borrowerID, r.Loan.LenderID, r.Amount)
+		for i := range s.loans {
+			if s.loans[i].ID == r.Loan.ID {
+				s.loans[i] = r.Loan
+			}
+		}
+	}
+	return repayments, nil
+}
+
+func (s *fakeStore) ListLoans(ctx context.Context, playerID string) ([]model.Loan, error) {
+	var loans []model.Loan
+	for _, l := range s.loans {
+		if !l.Closed && (l.LenderID == playerID || l.BorrowerID == playerID) {
+			loans = append(loans, l)
+		}
+	}
+	return loans, nil
+}
+
+func (s *fakeStore) ListDueLoans(ctx context.Context, before time.Time) ([]model.Loan, error) {
+	var loans []model.Loan
+	for _, l := range s.loans {
+		if !l.Closed && !l.DueAt.IsZero() && l.DueAt.Before(before) {
+			loans = append(loans, l)
+		}
+	}
+	return loans, nil
+}
+
+func (s *fakeStore) MarkLoanReminded(ctx context.Context, id string, at time.Time) error {
+	for i := range s.loans {
+		if s.loans[i].ID == id {
+			s.loans[i].RemindedAt = at
+			return nil
+		}
+	}
+	return model.ErrNotFound
+}
+
+func (s *fakeStore) GetFaucet(ctx context.Context, playerID string) (*model.Faucet, error) {
+	f := s.faucets[playerID]
+	f.PlayerID = playerID
+	return &f, nil
+}
+
+func (s *fakeStore) UpdateFaucet(ctx context.Context, playerID string, reason model.LedgerReason, fn func(f *model.Faucet, balance int64) (int64, error)) (*model.Player, error) {
+	f, _ := s.GetFaucet(ctx, playerID)
+	f.Reliefs = append([]time.Time(nil), f.Reliefs...)
+	amount, err := fn(f, 1000+s.balances[playerID])
+	if err != nil {
+		return nil, err
+	}
+	s.faucets[playerID] = *f
+	if amount > 0 {
+		s.balances[playerID] += amount
+	}
+	return s.GetPlayerByID(ctx, playerID)
+}
+
+// ListPlayers returns the players whose balance has changed
+func (s *fakeStore) ListPlayers(ctx context.Context) ([]model.Player, error) {
+	ids := make([]string, 0, len(s.balances))
+	for id := range s.balances {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var players []model.Player
+	for _, id := range ids {
+		p, _ := s.GetPlayerByID(ctx, id)
+		players = append(players, *p)
+	}
+	return players, nil
+}
+
+func (s *fakeStore) ListActivePlayers(ctx context.Context) ([]model.Player, error) {
+	return s.ListPlayers(ctx)
+}
+
+func (s *fakeStore) ListLedgerEntries(ctx context.Context, account string, limit int) ([]model.LedgerEntry, error) {
+	var entries []model.LedgerEntry
+	for i := len(s.ledger) - 1; i >= 0 && len(entries) < limit; i-- {
+		if s.ledger[i].Account == account {
+			entries = append(entries, s.ledger[i])
+		}
+	}
+	return entries, nil
+}
+
+func (s *fakeStore) LedgerBalances(ctx context.Context) (map[string]int64, error) {
+	balances := map[string]int64{}
+	for _, e := range s.ledger {
+		balances[e.Account] += e.Amount
+	}
+	return balances, nil
+}
@@ -1,9 +1,12 @@
 package game
 
 import (
+	"encoding/json"
 	"fmt"
+	"os"
 	"sort"
 	"strings"
+	"sync"
 
 	"github.com/psucodervn/verixilac/internal/model"
 )
@@ -13,6 +16,30 @@ type Rule struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Multipliers map[PlayerType]map[model.ResultType]int64
+
+	// PlayerMinValue and DealerMinValue are the minimum values to stand (đủ tẩy)
+	PlayerMinValue int `json:"player_min_value"`
+	DealerMinValue int `json:"dealer_min_value"`
+	// TooHighValue is the value from which a hand is "đền"
+	TooHighValue int `json:"too_high_value"`
+	// HighFiveCards is the number of cards of a "ngũ linh" hand
+	HighFiveCards int `json:"high_five_cards"`
+	// AceValues are the values an ace can take besides 1, tried in order
+	AceValues []int `json:"ace_values"`
+	// AceOneFromCards is the number of cards from which aces always count as 1
+	AceOneFromCards int `json:"ace_one_from_cards"`
+	// RevealLimits restrict which participants the dealer may reveal (xét bài) depending on the dealer's hand
+	RevealLimits []RevealLimit `json:"reveal_limits"`
+}
+
+// RevealLimit applies while the dealer's value is in [DealerMinValue, DealerMaxValue] and the dealer
+// holds at most DealerMaxCards cards (zero means no bound), the dealer may then only reveal
+// participants holding at least MinCards cards
+type RevealLimit struct {
+	DealerMinValue int `json:"dealer_min_value,omitempty"`
+	DealerMaxValue int `json:"dealer_max_value,omitempty"`
+	DealerMaxCards int `json:"dealer_max_cards,omitempty"`
+	MinCards       int `json:"min_cards"`
 }
 
 var (
@@ -34,6 +61,12 @@ var (
 					model.TypeBlackJack:       2,
 				},
 			},
+			PlayerMinValue:  16,
+			DealerMinValue:  15,
+			TooHighValue:    28,
+			HighFiveCards:   5,
+			AceValues:       []int{11, 10},
+			AceOneFromCards: 4,
 		},
 		"2": {
 			ID:          "2",
@@ -44,24 +77,415 @@ var (
 					model.TypeDoubleBlackJack: 2,
 				},
 			},
+			PlayerMinValue:  16,
+			DealerMinValue:  15,
+			TooHighValue:    28,
+			HighFiveCards:   5,
+			AceValues:       []int{11, 10},
+			AceOneFromCards: 4,
 		},
 	}
-	DefaultRule   = DefaultRules[DefaultRuleID]
-	SortedRuleIDs []string
-	RuleListText  string
+	DefaultRule = DefaultRules[DefaultRuleID]
+
+	// Rules holds the rules which can be used in games, it starts with DefaultRules
+	Rules = NewRuleSet()
+)
+
+var (
+	playerTypeKeys = map[PlayerType]string{
+		Dealer:      "dealer",
+		Participant: "participant",
+	}
+	resultTypeKeys = map[model.ResultType]string{
+		model.TypeDoubleBlackJack: "double_blackjack",
+		model.TypeBlackJack:       "blackjack",
+		model.TypeHighFive:        "high_five",
+		model.TypeNormal:          "normal",
+		model.TypeBusted:          "busted",
+		model.TypeTooHigh:         "too_high",
+		model.TypeTooLow:          "too_low",
+	}
 )
 
 func init() {
-	for id := range DefaultRules {
-		SortedRuleIDs = append(SortedRuleIDs, id)
+	for _, r := range DefaultRules {
+		Rules.rules[r.ID] = r
+	}
+	Rules.refresh()
+}
+
+// Validate fills the missing thresholds with the default ones and checks if the rule is playable
+func (r *Rule) Validate() error {
+	if len(r.ID) == 0 || strings.ContainsAny(r.ID, " \t\n") {
+		return fmt.Errorf("rule id không hợp lệ: %q", r.ID)
+	}
+	if len(strings.TrimSpace(r.Name)) == 0 {
+		return fmt.Errorf("rule %s: thiếu tên", r.ID)
+	}
+
+	if r.PlayerMinValue == 0 {
+		r.PlayerMinValue = DefaultRule.PlayerMinValue
+	}
+	if r.DealerMinValue == 0 {
+		r.DealerMinValue = DefaultRule.DealerMinValue
+	}
+	if r.TooHighValue == 0 {
+		r.TooHighValue = DefaultRule.TooHighValue
+	}
+	if r.HighFiveCards == 0 {
+		r.HighFiveCards = DefaultRule.HighFiveCards
+	}
+	if r.AceValues == nil {
+		r.AceValues = DefaultRule.AceValues
+	}
+	if r.AceOneFromCards == 0 {
+		r.AceOneFromCards = DefaultRule.AceOneFromCards
+	}
+
+	if r.PlayerMinValue < 2 || r.PlayerMinValue > 21 || r.DealerMinValue < 2 || r.DealerMinValue > 21 {
+		return fmt.Errorf("rule %s: điểm tẩy phải từ 2 đến 21", r.ID)
+	}
+	if r.TooHighValue <= 21 {
+		return fmt.Errorf("rule %s: điểm đền phải lớn hơn 21", r.ID)
+	}
+	if r.HighFiveCards < 3 || r.HighFiveCards > 11 {
+		return fmt.Errorf("rule %s: số lá ngũ linh phải từ 3 đến 11", r.ID)
+	}
+	for _, v := range r.AceValues {
+		if v < 2 || v > 11 {
+			return fmt.Errorf("rule %s: giá trị của A phải từ 2 đến 11", r.ID)
+		}
+	}
+	if r.AceOneFromCards < 3 {
+		return fmt.Errorf("rule %s: số lá để A tính 1 điểm phải từ 3 trở lên", r.ID)
 	}
-	sort.Strings(SortedRuleIDs)
+	for _, l := range r.RevealLimits {
+		if l.MinCards < 2 || l.DealerMinValue < 0 || l.DealerMaxValue < 0 || l.DealerMaxCards < 0 {
+			return fmt.Errorf("rule %s: giới hạn xét bài không hợp lệ", r.ID)
+		}
+		if l.DealerMaxValue > 0 && l.DealerMaxValue < l.DealerMinValue {
+			return fmt.Errorf("rule %s: giới hạn xét bài không hợp lệ", r.ID)
+		}
+	}
+	for pt, ms := range r.Multipliers {
+		if _, ok := playerTypeKeys[pt]; !ok {
+			return fmt.Errorf("rule %s: loại người chơi không hợp lệ: %d", r.ID, pt)
+		}
+		for rt, v := range ms {
+			if _, ok := resultTypeKeys[rt]; !ok {
+				return fmt.Errorf("rule %s: loại bài không hợp lệ: %d", r.ID, rt)
+			}
+			if v < 1 {
+				return fmt.Errorf("rule %s: hệ số của %s phải lớn hơn 0", r.ID, rt)
+			}
+		}
+	}
+	return nil
+}
+
+// Summary describes the thresholds of the rule
+func (r *Rule) Summary() string {
+	s := fmt.Sprintf("Tẩy: con %d, cái %d. Đền: %d. Ngũ linh: %d lá. A: %v hoặc 1, từ %d lá tính 1.",
+		r.PlayerMinValue, r.DealerMinValue, r.TooHighValue, r.HighFiveCards, r.AceValues, r.AceOneFromCards)
+	for _, l := range r.RevealLimits {
+		s += " Xét bài: " + l.String() + "."
+	}
+	return s
+}
+
+func (l RevealLimit) String() string {
+	s := "cái"
+	switch {
+	case l.DealerMinValue > 0 && l.DealerMinValue == l.DealerMaxValue:
+		s += fmt.Sprintf(" %d điểm", l.DealerMinValue)
+	case l.DealerMinValue > 0 && l.DealerMaxValue > 0:
+		s += fmt.Sprintf(" %d-%d điểm", l.DealerMinValue, l.DealerMaxValue)
+	case l.DealerMinValue > 0:
+		s += fmt.Sprintf(" từ %d điểm", l.DealerMinValue)
+	case l.DealerMaxValue > 0:
+		s += fmt.Sprintf(" tới %d điểm", l.DealerMaxValue)
+	}
+	if l.DealerMaxCards > 0 {
+		s += fmt.Sprintf(", tối đa %d lá", l.DealerMaxCards)
+	}
+	return s + fmt.Sprintf(" chỉ được lật con từ %d lá", l.MinCards)
+}
+
+// matches checks if the limit applies to a dealer with the given value and number of cards
+func (l RevealLimit) matches(value, cards int) bool {
+	return (l.DealerMinValue == 0 || value >= l.DealerMinValue) &&
+		(l.DealerMaxValue == 0 || value <= l.DealerMaxValue) &&
+		(l.DealerMaxCards == 0 || cards <= l.DealerMaxCards)
+}
+
+// CanReveal checks the reveal limits for the dealer revealing the participant
+func (r *Rule) CanReveal(dealer, participant *PlayerInGame) bool {
+	value, cards := dealer.Value(), len(dealer.Cards())
+	for _, l := range r.RevealLimits {
+		if l.matches(value, cards) && len(participant.Cards()) < l.MinCards {
+			return false
+		}
+	}
+	return true
+}
+
+// Payouts describes the multipliers of participants and dealer
+func (r *Rule) Payouts() string {
+	parts := make([]string, 0, 2)
+	for _, pt := range []PlayerType{Participant, Dealer} {
+		name := "Con"
+		if pt == Dealer {
+			name = "Cái"
+		}
+		var ms []string
+		for rt := model.TypeDoubleBlackJack; rt <= model.TypeTooLow; rt++ {
+			if v, ok := r.Multipliers[pt][rt]; ok && v != 1 {
+				ms = append(ms, fmt.Sprintf("%s x%d", strings.ToLower(rt.String()), v))
+			}
+		}
+		if len(ms) == 0 {
+			ms = append(ms, "x1")
+		}
+		parts = append(parts, name+": "+strings.Join(ms, ", "))
+	}
+	return strings.Join(parts, ". ")
+}
+
+// MaxMultiplier is the highest multiplier a participant can win, at least 1
+func (r *Rule) MaxMultiplier() int64 {
+	res := int64(1)
+	for _, v := range r.Multipliers[Participant] {
+		if v > res {
+			res = v
+		}
+	}
+	return res
+}
+
+func (r *Rule) orDefault() *Rule {
+	if r == nil {
+		return &DefaultRule
+	}
+	return r
+}
+
+type ruleJSON struct {
+	ID              string                      `json:"id"`
+	Name            string                      `json:"name"`
+	Description     string                      `json:"description"`
+	Multipliers     map[string]map[string]int64 `json:"multipliers,omitempty"`
+	PlayerMinValue  int                         `json:"player_min_value,omitempty"`
+	DealerMinValue  int                         `json:"dealer_min_value,omitempty"`
+	TooHighValue    int                         `json:"too_high_value,omitempty"`
+	HighFiveCards   int                         `json:"high_five_cards,omitempty"`
+	AceValues       []int                       `json:"ace_values"`
+	AceOneFromCards int                         `json:"ace_one_from_cards,omitempty"`
+	RevealLimits    []RevealLimit               `json:"reveal_limits,omitempty"`
+}
+
+// MarshalJSON writes multipliers with readable keys, e.g. {"dealer": {"double_blackjack": 3}}
+func (r Rule) MarshalJSON() ([]byte, error) {
+	v := ruleJSON{
+		ID:              r.ID,
+		Name:            r.Name,
+		Description:     r.Description,
+		Multipliers:     make(map[string]map[string]int64),
+		PlayerMinValue:  r.PlayerMinValue,
+		DealerMinValue:  r.DealerMinValue,
+		TooHighValue:    r.TooHighValue,
+		HighFiveCards:   r.HighFiveCards,
+		AceValues:       r.AceValues,
+		AceOneFromCards: r.AceOneFromCards,
+		RevealLimits:    r.RevealLimits,
+	}
+	for pt, ms := range r.Multipliers {
+		m := make(map[string]int64)
+		for rt, c := range ms {
+			m[resultTypeKeys[rt]] = c
+		}
+		v.Multipliers[playerTypeKeys[pt]] = m
+	}
+	return json.Marshal(v)
+}
+
+func (r *Rule) UnmarshalJSON(data []byte) error {
+	var v ruleJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	*r = Rule{
+		ID:              v.ID,
+		Name:            v.Name,
+		Description:     v.Description,
+		Multipliers:     make(map[PlayerType]map[model.ResultType]int64),
+		PlayerMinValue:  v.PlayerMinValue,
+		DealerMinValue:  v.DealerMinValue,
+		TooHighValue:    v.TooHighValue,
+		HighFiveCards:   v.HighFiveCards,
+		AceValues:       v.AceValues,
+		AceOneFromCards: v.AceOneFromCards,
+		RevealLimits:    v.RevealLimits,
+	}
+	for pk, ms := range v.Multipliers {
+		pt, ok := findKey(playerTypeKeys, pk)
+		if !ok {
+			return fmt.Errorf("invalid player type: %s", pk)
+		}
+		m := make(map[model.ResultType]int64)
+		for rk, c := range ms {
+			rt, ok := findKey(resultTypeKeys, rk)
+			if !ok {
+				return fmt.Errorf("invalid result type: %s", rk)
+			}
+			m[rt] = c
+		}
+		r.Multipliers[pt] = m
+	}
+	return nil
+}
+
+// ParseRules parses a rule or a list of rules in JSON and validates them
+func ParseRules(data []byte) ([]Rule, error) {
+	var rules []Rule
+	if s := strings.TrimSpace(string(data)); strings.HasPrefix(s, "{") {
+		var r Rule
+		if err := json.Unmarshal(data, &r); err != nil {
+			return nil, err
+		}
+		rules = append(rules, r)
+	} else if err := json.Unmarshal(data, &rules); err != nil {
+		return nil, err
+	}
+
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
+
+// LoadRulesFile reads rules from a JSON file
+func LoadRulesFile(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseRules(data)
+}
+
+type RuleSet struct {
+	rules     map[string]Rule
+	sortedIDs []string
+	listText  string
+
+	mu sync.RWMutex
+}
+
+func NewRuleSet() *RuleSet {
+	return &RuleSet{rules: make(map[string]Rule)}
+}
+
+func (s *RuleSet) Get(id string) (Rule, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.rules[id]
+	return r, ok
+}
+
+// Set validates and adds or replaces the rules
+func (s *RuleSet) Set(rules ...Rule) error {
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, r := range rules {
+		s.rules[r.ID] = r
+	}
+	s.refresh()
+	return nil
+}
+
+func (s *RuleSet) Delete(id string) error {
+	if id == DefaultRuleID {
+		return fmt.Errorf("không thể xoá rule mặc định")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.rules[id]; !ok {
+		return fmt.Errorf("không tìm thấy rule: %s", id)
+	}
+	delete(s.rules, id)
+	s.refresh()
+	return nil
+}
+
+// Reset replaces all rules with DefaultRules and the given ones
+func (s *RuleSet) Reset(rules ...Rule) error {
+	for i := range rules {
+		if err := rules[i].Validate(); err != nil {
+			return err
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rules = make(map[string]Rule)
+	for _, r := range DefaultRules {
+		s.rules[r.ID] = r
+	}
+	for _, r := range rules {
+		s.rules[r.ID] = r
+	}
+	s.refresh()
+	return nil
+}
+
+func (s *RuleSet) SortedIDs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.sortedIDs
+}
+
+func (s *RuleSet) ListText() string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.listText
+}
+
+// refresh regenerates the sorted IDs and the list text, s.mu must be held
+func (s *RuleSet) refresh() {
+	ids := make([]string, 0, len(s.rules))
+	for id := range s.rules {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
 
 	var bf strings.Builder
 	bf.WriteString(`Danh sách rules:`)
-	for _, id := range SortedRuleIDs {
-		bf.WriteString(fmt.Sprintf("\n\n - Rule: %s, ID: %s", DefaultRules[id].Name, id))
-		bf.WriteString(fmt.Sprintf("\n%s", DefaultRules[id].Description))
+	for _, id := range ids {
+		r := s.rules[id]
+		bf.WriteString(fmt.Sprintf("\n\n - Rule: %s, ID: %s", r.Name, id))
+		bf.WriteString(fmt.Sprintf("\n%s", r.Description))
+		bf.WriteString(fmt.Sprintf("\n%s", r.Summary()))
+	}
+
+	s.sortedIDs = ids
+	s.listText = bf.String()
+}
+
+func findKey[K comparable](m map[K]string, v string) (K, bool) {
+	for k, s := range m {
+		if s == v {
+			return k, true
+		}
 	}
-	RuleListText = bf.String()
+	var k K
+	return k, false
 }
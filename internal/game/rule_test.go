@@ -0,0 +1,130 @@
+package game
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestParseRules(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantErr bool
+		want    int
+	}{
+		{name: "single", data: `{"id": "3", "name": "Test", "multipliers": {"dealer": {"double_blackjack": 2}}}`, want: 1},
+		{name: "list", data: `[{"id": "3", "name": "A"}, {"id": "4", "name": "B", "too_high_value": 30}]`, want: 2},
+		{name: "missing name", data: `{"id": "3"}`, wantErr: true},
+		{name: "invalid id", data: `{"id": "3 4", "name": "A"}`, wantErr: true},
+		{name: "invalid player type", data: `{"id": "3", "name": "A", "multipliers": {"house": {"blackjack": 2}}}`, wantErr: true},
+		{name: "invalid result type", data: `{"id": "3", "name": "A", "multipliers": {"dealer": {"royal": 2}}}`, wantErr: true},
+		{name: "invalid multiplier", data: `{"id": "3", "name": "A", "multipliers": {"dealer": {"blackjack": 0}}}`, wantErr: true},
+		{name: "invalid too high", data: `{"id": "3", "name": "A", "too_high_value": 21}`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRules([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseRules() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != tt.want {
+				t.Errorf("ParseRules() = %d rules, want %d", len(got), tt.want)
+			}
+			for _, r := range got {
+				if r.PlayerMinValue == 0 || r.DealerMinValue == 0 || r.TooHighValue == 0 || r.HighFiveCards == 0 {
+					t.Errorf("ParseRules() = %+v, want thresholds filled", r)
+				}
+			}
+		})
+	}
+}
+
+func TestRule_JSON(t *testing.T) {
+	bs, err := json.Marshal(DefaultRule)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	var r Rule
+	if err := json.Unmarshal(bs, &r); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if got := r.Multipliers[Participant][model.TypeDoubleBlackJack]; got != 3 {
+		t.Errorf("Multipliers = %v, want %v", got, 3)
+	}
+	if r.TooHighValue != DefaultRule.TooHighValue {
+		t.Errorf("TooHighValue = %v, want %v", r.TooHighValue, DefaultRule.TooHighValue)
+	}
+}
+
+func TestRuleSet(t *testing.T) {
+	s := NewRuleSet()
+	if err := s.Reset(Rule{ID: "9", Name: "Nine"}); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if got := s.SortedIDs(); len(got) != len(DefaultRules)+1 || got[len(got)-1] != "9" {
+		t.Errorf("SortedIDs() = %v", got)
+	}
+	if err := s.Delete(DefaultRuleID); err == nil {
+		t.Errorf("Delete() default rule should fail")
+	}
+	if err := s.Delete("9"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, ok := s.Get("9"); ok {
+		t.Errorf("Get() deleted rule should fail")
+	}
+}
+
+func TestRule_Payouts(t *testing.T) {
+	tests := []struct {
+		ruleID string
+		want   string
+	}{
+		{ruleID: "1", want: "Con: xì bàn x3, xì lác x2, ngũ linh x2. Cái: xì bàn x3, xì lác x2, ngũ linh x2"},
+		{ruleID: "2", want: "Con: xì bàn x2. Cái: x1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.ruleID, func(t *testing.T) {
+			r := DefaultRules[tt.ruleID]
+			if got := r.Payouts(); got != tt.want {
+				t.Errorf("Payouts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRule_CanReveal(t *testing.T) {
+	hand := func(isDealer bool, ids ...int) *PlayerInGame {
+		pg := NewPlayerInGame(&model.Player{}, &DefaultRule, 10, isDealer)
+		for _, id := range ids {
+			pg.AddCard(Card{id: id})
+		}
+		return pg
+	}
+	rule := DefaultRule
+	rule.RevealLimits = []RevealLimit{
+		{DealerMinValue: 15, DealerMaxValue: 15, MinCards: 3},
+		{DealerMinValue: 16, DealerMaxValue: 17, DealerMaxCards: 2, MinCards: 4},
+	}
+	tests := []struct {
+		name        string
+		dealer      *PlayerInGame
+		participant *PlayerInGame
+		want        bool
+	}{
+		{name: "dealer 15, 2 cards", dealer: hand(true, 9, 4), participant: hand(false, 1, 2), want: false},
+		{name: "dealer 15, 3 cards", dealer: hand(true, 9, 4), participant: hand(false, 1, 2, 3), want: true},
+		{name: "dealer 17 with 2 cards, 3 cards", dealer: hand(true, 9, 6), participant: hand(false, 1, 2, 3), want: false},
+		{name: "dealer 17 with 2 cards, 4 cards", dealer: hand(true, 9, 6), participant: hand(false, 1, 2, 3, 14), want: true},
+		{name: "dealer 18, no limit", dealer: hand(true, 9, 7), participant: hand(false, 1, 2), want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := rule.CanReveal(tt.dealer, tt.participant); got != tt.want {
+				t.Errorf("CanReveal() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
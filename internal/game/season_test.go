@@ -0,0 +1,81 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_Season(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	admin := &model.Player{ID: "9", Name: "Admin"}
+	p1 := &model.Player{ID: "1", Name: "Player #1"}
+	store.balances = map[string]int64{"1": 500, "2": -200, "3": 0}
+	_ = store.SaveRecord(ctx, &model.Record{PlayerID: "1", Reward: 30, CreatedAt: time.Now()})
+
+	// the bets of a dealt game must not move to the next season
+	store.escrows["g1"] = model.Escrow{GameID: "g1"}
+	if _, err := m.ResetSeason(ctx, admin, 2000); !errors.Is(err, model.ErrUnsettledGames) {
+		t.Fatalf("ResetSeason() error = %v, want %v", err, model.ErrUnsettledGames)
+	}
+	store.escrows["g1"] = model.Escrow{GameID: "g1", Settled: true}
+
+	res, err := m.ResetSeason(ctx, admin, 2000)
+	if err != nil {
+		t.Fatalf("ResetSeason() error = %v", err)
+	}
+	for _, s := range []string{"🏁 Mùa 1", "1. `Player #1`: 1.500☘️", "3. `Player #2`: 800☘️", "Mùa 2 bắt đầu"} {
+		if !strings.Contains(res, s) {
+			t.Errorf("ResetSeason() = %q, want to contain %q", res, s)
+		}
+	}
+	store.balances["3"] = 1500
+	_ = store.SaveRecord(ctx, &model.Record{PlayerID: "1", Reward: -10, CreatedAt: time.Now().Add(time.Second)})
+
+	tests := []struct {
+		name    string
+		number  int
+		want    []string
+		wantErr error
+	}{
+		{
+			name:   "running",
+			number: 0,
+			want:   []string{"🏆 Mùa 2", "1. `Player #3`: 2.500☘️", "`Player #1`: 2.000☘️ (1 ván, thắng 0, lãi/lỗ -10☘️)", "/season 1"},
+		},
+		{
+			name:   "running by number",
+			number: 2,
+			want:   []string{"🏆 Mùa 2"},
+		},
+		{
+			name:   "closed",
+			number: 1,
+			want:   []string{"🏁 Mùa 1 (khai trương - ", "1. `Player #1`: 1.500☘️"},
+		},
+		{
+			name:    "unknown",
+			number:  3,
+			wantErr: ErrSeasonNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Season(ctx, p1, tt.number)
+			if err != tt.wantErr {
+				t.Fatalf("Season() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("Season() = %q, want to contain %q", got, s)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package game
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_Settle(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Shuffler: NewSeededShuffler(5)})
+	g := playGame(t, m)
+
+	check := func() {
+		t.Helper()
+		sum := int64(0)
+		for _, pg := range g.AllPlayers() {
+			sum += store.balances[pg.ID]
+			if got, want := store.balances[pg.ID], pg.Reward(); got != want {
+				t.Errorf("balance change of %s = %d, want %d", pg.Name, got, want)
+			}
+		}
+		if sum != 0 {
+			t.Errorf("balance changes sum = %d, want 0", sum)
+		}
+	}
+	check()
+
+	// retry must not pay or record twice
+	results, events := len(store.results), len(store.events[g.ID()])
+	if err := m.FinishGame(ctx, g, true); !errors.Is(err, ErrGameFinished) {
+		t.Fatalf("FinishGame() error = %v, want %v", err, ErrGameFinished)
+	}
+	check()
+	if len(store.results) != results || len(store.events[g.ID()]) != events {
+		t.Errorf("retry saved %d records and %d events, want none", len(store.results)-results, len(store.events[g.ID()])-events)
+	}
+}
+
+func TestManager_Escrow(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	p := &model.Player{ID: "2", Name: "Player #2", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	_, _ = m.JoinRoom(ctx, p, r.ID())
+	g, _ := m.NewGame(dealer, "")
+	if err := m.PlayerBet(ctx, g.ID(), p, 50); err != nil {
+		t.Fatalf("PlayerBet() error = %v", err)
+	}
+
+	// the balance was spent somewhere else after betting
+	store.balances[p.ID] = -980
+	if _, err := m.Deal(ctx, g.ID()); !errors.Is(err, model.ErrInsufficientBalance) {
+		t.Fatalf("Deal() error = %v, want %v", err, model.ErrInsufficientBalance)
+	}
+	if g.Status() != Betting || len(g.Dealer().Cards()) != 0 {
+		t.Errorf("game was dealt without escrow: status = %v", g.Status())
+	}
+
+	store.balances[p.ID] = 0
+	if _, err := m.Deal(ctx, g.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+	if got := store.balances[p.ID]; got != -50 {
+		t.Errorf("balance change after deal = %d, want %d", got, -50)
+	}
+
+	// the bets stay in the game until they are refunded
+	store.settleErr = errors.New("disk full")
+	if _, err := m.CancelGame(ctx, g.ID()); !errors.Is(err, store.settleErr) {
+		t.Fatalf("CancelGame() error = %v, want %v", err, store.settleErr)
+	}
+	if r.Game() != g {
+		t.Fatalf("game was dropped although its bets were not refunded")
+	}
+	store.settleErr = nil
+	if _, err := m.CancelGame(ctx, g.ID()); err != nil {
+		t.Fatalf("CancelGame() error = %v", err)
+	}
+	if r.Game() != nil || store.records[g.ID()].Status != model.GameCancelled {
+		t.Errorf("cancelled game was not cleared and recorded")
+	}
+	if got := store.balances[p.ID]; got != 0 {
+		t.Errorf("balance change after cancel = %d, want %d", got, 0)
+	}
+}
+
+func TestManager_DealerBalance(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	g, _ := m.NewGame(dealer, "")
+	p2 := &model.Player{ID: "2", Name: "Player #2", Balance: 1000}
+	p3 := &model.Player{ID: "3", Name: "Player #3", Balance: 1000}
+	_, _ = m.JoinRoom(ctx, p2, r.ID())
+	_, _ = m.JoinRoom(ctx, p3, r.ID())
+	if err := m.PlayerBet(ctx, g.ID(), p2, 100); err != nil {
+		t.Fatalf("PlayerBet() error = %v", err)
+	}
+
+	// the dealer gave money away while betting, the rule pays participants up to x3
+	store.balances[dealer.ID] = -500
+	if err := m.PlayerBet(ctx, g.ID(), p3, 100); err != nil {
+		t.Fatalf("PlayerBet() error = %v", err)
+	}
+	if pg := g.FindPlayer(p3.ID); pg == nil || pg.BetAmount() != 66 {
+		t.Fatalf("bet of %s = %v, want 66", p3.Name, pg)
+	}
+	store.balances[dealer.ID] = -600
+	if _, err := m.Deal(ctx, g.ID()); !errors.Is(err, ErrDealerBankFull) {
+		t.Fatalf("Deal() error = %v, want %v", err, ErrDealerBankFull)
+	}
+	if g.Status() != Betting {
+		t.Errorf("game was dealt over the dealer's bank: status = %v", g.Status())
+	}
+}
+
+func TestManager_FinishGameConcurrently(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Persist: true, Shuffler: NewSeededShuffler(3)})
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	g, _ := m.NewGame(dealer, "")
+	for _, id := range []string{"2", "3"} {
+		p := &model.Player{ID: id, Name: "Player #" + id, Balance: 1000}
+		_, _ = m.JoinRoom(ctx, p, r.ID())
+		if err := m.PlayerBet(ctx, g.ID(), p, 10); err != nil {
+			t.Fatalf("PlayerBet() error = %v", err)
+		}
+	}
+	if _, err := m.Deal(ctx, g.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+
+	// the turn timer and the dealer finish the game at the same time
+	errs := make(chan error, 4)
+	for i := 0; i < cap(errs); i++ {
+		go func() { errs <- m.FinishGame(ctx, g, true) }()
+	}
+	finished := 0
+	for i := 0; i < cap(errs); i++ {
+		if err := <-errs; err == nil {
+			finished++
+		} else if !errors.Is(err, ErrGameFinished) {
+			t.Errorf("FinishGame() error = %v, want nil or %v", err, ErrGameFinished)
+		}
+	}
+	if finished != 1 {
+		t.Errorf("FinishGame() succeeded %d times, want 1", finished)
+	}
+	if got, want := len(store.results), 3; got != want {
+		t.Errorf("saved %d records, want %d", got, want)
+	}
+	sum := int64(0)
+	for _, pg := range g.AllPlayers() {
+		sum += store.balances[pg.ID]
+	}
+	if sum != 0 {
+		t.Errorf("balance changes sum = %d, want 0", sum)
+	}
+}
+
+func TestManager_FinishSettledGame(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	p := &model.Player{ID: "2", Name: "Player #2", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	_, _ = m.JoinRoom(ctx, p, r.ID())
+	g, _ := m.NewGame(dealer, "")
+	if err := m.PlayerBet(ctx, g.ID(), p, 50); err != nil {
+		t.Fatalf("PlayerBet() error = %v", err)
+	}
+	if _, err := m.Deal(ctx, g.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+
+	// the bot stopped after the game was paid but before the room was saved
+	e := store.escrows[g.ID()]
+	e.Settled = true
+	store.escrows[g.ID()] = e
+
+	if err := m.FinishGame(ctx, g, true); !errors.Is(err, ErrGameFinished) {
+		t.Fatalf("FinishGame() error = %v, want %v", err, ErrGameFinished)
+	}
+	if r.Game() != nil || store.rooms[r.ID()].Game != nil {
+		t.Errorf("settled game was not cleared from the room")
+	}
+	if len(store.results) != 0 || len(store.records) != 0 {
+		t.Errorf("settled game was recorded again: %d records, %d games", len(store.results), len(store.records))
+	}
+	if _, err := m.NewGame(dealer, ""); err != nil {
+		t.Errorf("NewGame() error = %v", err)
+	}
+}
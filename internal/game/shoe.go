@@ -0,0 +1,129 @@
+package game
+
+import (
+	"sync"
+)
+
+const (
+	DeckSize           = 52
+	DefaultPenetration = 0.75
+)
+
+type ShoeConfig struct {
+	Decks       int
+	Penetration float64
+	// Persist keeps the shoe between games in a room instead of using a fresh one each game
+	Persist bool
+	// Shuffler defaults to CryptoShuffler
+	Shuffler Shuffler
+}
+
+var DefaultShoeConfig = ShoeConfig{Decks: 1, Penetration: DefaultPenetration}
+
+// Shoe holds one or more decks, cards are drawn in order until the cut card
+// (penetration) is reached, then the shoe is reshuffled before the next deal
+type Shoe struct {
+	decks       int
+	penetration float64
+	shuffler    Shuffler
+	shared      bool // kept by the room between games
+	seed        []byte
+	cards       Cards
+	pos         int
+
+	mu sync.Mutex
+}
+
+func NewShoe(decks int, penetration float64, shuffler Shuffler) *Shoe {
+	if decks < 1 {
+		decks = 1
+	}
+	if penetration <= 0 || penetration > 1 {
+		penetration = DefaultPenetration
+	}
+	if shuffler == nil {
+		shuffler = CryptoShuffler{}
+	}
+	return &Shoe{decks: decks, penetration: penetration, shuffler: shuffler}
+}
+
+func newShoe(cfg ShoeConfig) *Shoe {
+	return NewShoe(cfg.Decks, cfg.Penetration, cfg.Shuffler)
+}
+
+func (s *Shoe) Decks() int {
+	return s.decks
+}
+
+// Size is the number of cards of a full shoe
+func (s *Shoe) Size() int {
+	return s.decks * DeckSize
+}
+
+// Position returns the seed of the current shuffle and the index of the next card,
+// ShuffleCards(decks, seed)[pos:] are the cards left in the shoe
+func (s *Shoe) Position() (seed []byte, pos int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.seed, s.pos
+}
+
+func (s *Shoe) Remaining() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.cards) - s.pos
+}
+
+// MaxPlayers is the number of participants the shoe can serve in one game,
+// assuming everyone including the dealer draws up to the "ngũ linh" limit
+func (s *Shoe) MaxPlayers(rule *Rule) int {
+	return s.Size()/rule.orDefault().HighFiveCards - 1
+}
+
+// Prepare makes sure at least need cards are left, it reshuffles the shoe if the
+// cut card was reached or there are not enough cards
+func (s *Shoe) Prepare(need int) error {
+	if need > s.Size() {
+		return ErrTooManyPlayers
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if len(s.cards) == 0 || s.pos >= s.cutPos() || len(s.cards)-s.pos < need {
+		s.shuffle(s.shuffler.NewSeed())
+	}
+	return nil
+}
+
+// Reset reshuffles the whole shoe by the given seed
+func (s *Shoe) Reset(need int, seed []byte) error {
+	if need > s.Size() {
+		return ErrTooManyPlayers
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shuffle(seed)
+	return nil
+}
+
+func (s *Shoe) Draw() (Card, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.pos >= len(s.cards) {
+		return Card{}, ErrShoeEmpty
+	}
+	c := s.cards[s.pos]
+	s.pos++
+	return c, nil
+}
+
+func (s *Shoe) cutPos() int {
+	return int(float64(len(s.cards)) * s.penetration)
+}
+
+func (s *Shoe) shuffle(seed []byte) {
+	s.seed = seed
+	s.cards = ShuffleCards(s.decks, s.seed)
+	s.pos = 0
+}
@@ -0,0 +1,92 @@
+package game
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestShoe_Draw(t *testing.T) {
+	tests := []struct {
+		name  string
+		decks int
+	}{
+		{name: "single deck", decks: 1},
+		{name: "six decks", decks: 6},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewShoe(tt.decks, 1, NewSeededShuffler(1))
+			if err := s.Prepare(1); err != nil {
+				t.Fatalf("Prepare() error = %v", err)
+			}
+			counts := make(map[int]int)
+			for i := 0; i < tt.decks*DeckSize; i++ {
+				c, err := s.Draw()
+				if err != nil {
+					t.Fatalf("Draw() #%d error = %v", i, err)
+				}
+				counts[c.id]++
+			}
+			if len(counts) != DeckSize {
+				t.Errorf("Draw() got %d distinct cards, want %d", len(counts), DeckSize)
+			}
+			for id, n := range counts {
+				if n != tt.decks {
+					t.Errorf("card %d drawn %d times, want %d", id, n, tt.decks)
+				}
+			}
+			if _, err := s.Draw(); !errors.Is(err, ErrShoeEmpty) {
+				t.Errorf("Draw() on empty shoe error = %v, want %v", err, ErrShoeEmpty)
+			}
+		})
+	}
+}
+
+func TestShoe_Prepare(t *testing.T) {
+	tests := []struct {
+		name       string
+		decks      int
+		drawn      int
+		need       int
+		wantErr    error
+		wantRemain int
+	}{
+		{name: "before cut card", decks: 1, drawn: 20, need: 10, wantRemain: 32},
+		{name: "cut card reached", decks: 1, drawn: 39, need: 10, wantRemain: 52},
+		{name: "not enough cards", decks: 2, drawn: 70, need: 40, wantRemain: 104},
+		{name: "more than the shoe", decks: 1, need: 53, wantErr: ErrTooManyPlayers},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewShoe(tt.decks, DefaultPenetration, NewSeededShuffler(1))
+			_ = s.Prepare(0)
+			for i := 0; i < tt.drawn; i++ {
+				_, _ = s.Draw()
+			}
+			if err := s.Prepare(tt.need); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Prepare() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if got := s.Remaining(); got != tt.wantRemain {
+				t.Errorf("Remaining() = %v, want %v", got, tt.wantRemain)
+			}
+		})
+	}
+}
+
+func TestShoe_MaxPlayers(t *testing.T) {
+	tests := []struct {
+		decks int
+		want  int
+	}{
+		{decks: 1, want: 9},
+		{decks: 2, want: 19},
+	}
+	for _, tt := range tests {
+		if got := NewShoe(tt.decks, DefaultPenetration, nil).MaxPlayers(&DefaultRule); got != tt.want {
+			t.Errorf("MaxPlayers(%d decks) = %v, want %v", tt.decks, got, tt.want)
+		}
+	}
+}
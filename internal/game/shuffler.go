@@ -0,0 +1,106 @@
+package game
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	mrand "math/rand"
+	"sync"
+)
+
+const SeedSize = 32
+
+// Shuffler provides the seeds used to shuffle the shoe. The order of the cards
+// only depends on the seed, so a game can be replayed from its recorded seed.
+type Shuffler interface {
+	NewSeed() []byte
+}
+
+// CryptoShuffler uses crypto/rand seeds, it is the one used in production
+type CryptoShuffler struct{}
+
+func (CryptoShuffler) NewSeed() []byte {
+	seed := make([]byte, SeedSize)
+	if _, err := rand.Read(seed); err != nil {
+		panic(err)
+	}
+	return seed
+}
+
+// SeededShuffler generates a reproducible sequence of seeds, for tests
+type SeededShuffler struct {
+	rnd *mrand.Rand
+	mu  sync.Mutex
+}
+
+func NewSeededShuffler(seed int64) *SeededShuffler {
+	return &SeededShuffler{rnd: mrand.New(mrand.NewSource(seed))}
+}
+
+func (s *SeededShuffler) NewSeed() []byte {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seed := make([]byte, SeedSize)
+	_, _ = s.rnd.Read(seed)
+	return seed
+}
+
+// ShuffleCards returns the cards of the given number of decks shuffled by seed
+func ShuffleCards(decks int, seed []byte) Cards {
+	n := decks * DeckSize
+	cards := make(Cards, n)
+	for i := 0; i < n; i++ {
+		cards[i] = Card{id: i % DeckSize}
+	}
+
+	// Fisher-Yates
+	st := newSeedStream(seed)
+	for i := n - 1; i > 0; i-- {
+		j := st.intn(uint64(i + 1))
+		cards[i], cards[j] = cards[j], cards[i]
+	}
+	return cards
+}
+
+// ReplayCards returns the cards drawn from a shoe shuffled by seed, starting at offset
+func ReplayCards(decks int, seed []byte, offset int) Cards {
+	cards := ShuffleCards(decks, seed)
+	if offset < 0 || offset > len(cards) {
+		return nil
+	}
+	return cards[offset:]
+}
+
+// seedStream is a deterministic random stream: sha256(seed || counter) blocks
+type seedStream struct {
+	seed    []byte
+	counter uint64
+	buf     []byte
+}
+
+func newSeedStream(seed []byte) *seedStream {
+	return &seedStream{seed: seed}
+}
+
+func (s *seedStream) uint64() uint64 {
+	if len(s.buf) < 8 {
+		h := sha256.New()
+		h.Write(s.seed)
+		_ = binary.Write(h, binary.BigEndian, s.counter)
+		s.counter++
+		s.buf = h.Sum(nil)
+	}
+	v := binary.BigEndian.Uint64(s.buf)
+	s.buf = s.buf[8:]
+	return v
+}
+
+// intn returns a uniform number in [0, n), without modulo bias
+func (s *seedStream) intn(n uint64) uint64 {
+	limit := ^uint64(0) - ^uint64(0)%n
+	for {
+		if v := s.uint64(); v < limit {
+			return v % n
+		}
+	}
+}
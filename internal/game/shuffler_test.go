@@ -0,0 +1,74 @@
+package game
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestShuffleCards(t *testing.T) {
+	seed := NewSeededShuffler(1).NewSeed()
+	a, b := ShuffleCards(2, seed), ShuffleCards(2, seed)
+	if a.String(nil, false, false) != b.String(nil, false, false) {
+		t.Errorf("ShuffleCards() with the same seed = %v, want %v", b.String(nil, false, false), a.String(nil, false, false))
+	}
+	if c := ShuffleCards(2, NewSeededShuffler(2).NewSeed()); a.String(nil, false, false) == c.String(nil, false, false) {
+		t.Errorf("ShuffleCards() with different seeds returned the same order")
+	}
+	if len(a) != 2*DeckSize {
+		t.Errorf("ShuffleCards() = %d cards, want %d", len(a), 2*DeckSize)
+	}
+}
+
+func TestSeededShuffler(t *testing.T) {
+	s1, s2 := NewSeededShuffler(42), NewSeededShuffler(42)
+	for i := 0; i < 3; i++ {
+		if a, b := s1.NewSeed(), s2.NewSeed(); !bytes.Equal(a, b) {
+			t.Errorf("NewSeed() #%d = %x, want %x", i, b, a)
+		}
+	}
+}
+
+func TestGame_ReplayCards(t *testing.T) {
+	ctx := context.Background()
+	deal := func() *Game {
+		m := NewManager(newFakeStore(), 100, 0, time.Minute, ShoeConfig{Decks: 1, Shuffler: NewSeededShuffler(7)})
+		dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+		p := &model.Player{ID: "2", Name: "Player #2", Balance: 1000}
+		r, _ := m.CreateRoom(ctx, dealer)
+		_, _ = m.JoinRoom(ctx, p, r.ID())
+		g, _ := m.NewGame(dealer, "")
+		if err := m.PlayerBet(ctx, g.ID(), p, 50); err != nil {
+			t.Fatalf("PlayerBet() error = %v", err)
+		}
+		if _, err := m.Deal(ctx, g.ID()); err != nil {
+			t.Fatalf("Deal() error = %v", err)
+		}
+		return g
+	}
+
+	// the shoe is shuffled by the server seed and the game id
+	g1, g2 := deal(), deal()
+	if g1.Commitment() != g2.Commitment() {
+		t.Errorf("games with the same shuffler seed have different commitments: %v, %v", g1.Commitment(), g2.Commitment())
+	}
+
+	// dealer first, one card each round
+	cs := g1.ReplayCards()
+	p := g1.PlayersInGame()[0]
+	want := []Card{cs[0], cs[2]}
+	for i, c := range g1.Dealer().Cards() {
+		if c != want[i] {
+			t.Errorf("dealer card #%d = %v, want %v", i, c, want[i])
+		}
+	}
+	want = []Card{cs[1], cs[3]}
+	for i, c := range p.Cards() {
+		if c != want[i] {
+			t.Errorf("player card #%d = %v, want %v", i, c, want[i])
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package game
+
+import (
+	"go.uber.org/atomic"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func (r *Room) snapshot() *model.RoomState {
+	st := &model.RoomState{
+		ID:      r.ID(),
+		Creator: r.Creator(),
+		MaxBet:  r.MaxBet(),
+		Timeout: r.Timeout(),
+		Window:  r.BettingWindow(),
+		RuleID:  r.Rule().ID,
+		Members: r.Members(),
+	}
+	r.mu.RLock()
+	if r.shoe != nil {
+		st.Shoe = r.shoe.snapshot()
+	}
+	r.mu.RUnlock()
+	if g := r.Game(); g != nil {
+		st.Game = g.snapshot()
+	}
+	return st
+}
+
+func restoreRoom(st *model.RoomState, shoeCfg ShoeConfig) *Room {
+	r := NewRoom(st.ID, st.Creator, findRule(st.RuleID), shoeCfg, st.MaxBet, st.Timeout)
+	r.members = append(r.members, st.Members...)
+	r.SetBettingWindow(st.Window)
+	if shoeCfg.Persist && st.Shoe != nil {
+		r.shoe = restoreShoe(st.Shoe, shoeCfg.Shuffler)
+		r.shoe.shared = true
+	}
+	return r
+}
+
+func (g *Game) snapshot() *model.GameState {
+	g.mu.RLock()
+	defer g.mu.RUnlock()
+
+	st := &model.GameState{
+		ID:          g.id,
+		RuleID:      g.rule.ID,
+		Status:      g.status.Load(),
+		CurrentIdx:  g.currentIdx,
+		MaxBet:      g.maxBet.Load(),
+		Timeout:     g.timeout.Load(),
+		CreatedAt:   g.createdAt,
+		Shoe:        g.shoe.snapshot(),
+		Seed:        g.seed,
+		ShoeOffset:  g.shoeOffset,
+		EventSeq:    g.lastEventSeq(),
+		ServerSeed:  g.serverSeed,
+		ClientSeeds: g.clientSeeds,
+		Dealer:      g.dealer.snapshot(),
+		Players:     make([]model.PlayerState, len(g.players)),
+	}
+	for i, pg := range g.players {
+		st.Players[i] = pg.snapshot()
+	}
+	return st
+}
+
+// restoreGame rebuilds a game from its snapshot, players are looked up by findPlayer
+func restoreGame(roomID string, st *model.GameState, shuffler Shuffler, findPlayer func(id string) *model.Player) *Game {
+	g := &Game{
+		id:          st.ID,
+		roomID:      roomID,
+		createdAt:   st.CreatedAt,
+		rule:        findRule(st.RuleID),
+		seed:        st.Seed,
+		shoeOffset:  st.ShoeOffset,
+		eventSeq:    st.EventSeq,
+		serverSeed:  st.ServerSeed,
+		clientSeeds: st.ClientSeeds,
+		currentIdx:  st.CurrentIdx,
+		status:      *atomic.NewUint32(st.Status),
+		maxBet:      *atomic.NewUint64(st.MaxBet),
+		timeout:     *atomic.NewDuration(st.Timeout),
+	}
+	switch {
+	case st.Shoe != nil:
+		g.shoe = restoreShoe(st.Shoe, shuffler)
+	default:
+		g.shoe = NewShoe(DefaultShoeConfig.Decks, DefaultShoeConfig.Penetration, shuffler)
+	}
+	g.dealer = restorePlayerInGame(st.Dealer, g.rule, findPlayer, true)
+
+	doneCnt := uint32(0)
+	for _, ps := range st.Players {
+		pg := restorePlayerInGame(ps, g.rule, findPlayer, false)
+		if !pg.IsDone() {
+			doneCnt++
+		}
+		g.players = append(g.players, pg)
+	}
+	g.doneCnt.Store(doneCnt)
+	return g
+}
+
+func (p *PlayerInGame) snapshot() model.PlayerState {
+	return model.PlayerState{
+		PlayerID: p.ID,
+		Cards:    cardIDs(p.Cards()),
+		Bet:      p.BetAmount(),
+		Status:   p.status.Load(),
+		Reward:   p.Reward(),
+		LastHit:  p.LastHit(),
+	}
+}
+
+func restorePlayerInGame(st model.PlayerState, rule *Rule, findPlayer func(id string) *model.Player, isDealer bool) *PlayerInGame {
+	p := findPlayer(st.PlayerID)
+	if p == nil {
+		p = &model.Player{ID: st.PlayerID, TelegramID: st.PlayerID}
+	}
+	pg := NewPlayerInGame(p, rule, int64(st.Bet), isDealer)
+	pg.cards = NewCards(st.Cards...)
+	pg.status.Store(st.Status)
+	pg.reward.Store(st.Reward)
+	pg.lastHit.Store(st.LastHit)
+	return pg
+}
+
+func (s *Shoe) snapshot() *model.ShoeState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &model.ShoeState{
+		Decks:       s.decks,
+		Penetration: s.penetration,
+		Seed:        s.seed,
+		Cards:       cardIDs(s.cards),
+		Pos:         s.pos,
+	}
+}
+
+func restoreShoe(st *model.ShoeState, shuffler Shuffler) *Shoe {
+	s := NewShoe(st.Decks, st.Penetration, shuffler)
+	s.seed = st.Seed
+	s.cards = NewCards(st.Cards...)
+	s.pos = st.Pos
+	return s
+}
+
+func cardIDs(cs Cards) []int {
+	ids := make([]int, len(cs))
+	for i := range cs {
+		ids[i] = cs[i].id
+	}
+	return ids
+}
+
+func findRule(id string) *Rule {
+	if r, ok := Rules.Get(id); ok {
+		return &r
+	}
+	return &DefaultRule
+}
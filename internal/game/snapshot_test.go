@@ -0,0 +1,59 @@
+package game
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_Restore(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	p := &model.Player{ID: "2", Name: "Player #2", Balance: 1000}
+
+	r, _ := m.CreateRoom(ctx, dealer)
+	_, _ = m.JoinRoom(ctx, p, r.ID())
+	g, err := m.NewGame(dealer, "")
+	if err != nil {
+		t.Fatalf("NewGame() error = %v", err)
+	}
+	if err := m.PlayerBet(ctx, g.ID(), p, 50); err != nil {
+		t.Fatalf("PlayerBet() error = %v", err)
+	}
+	if _, err := m.Deal(ctx, g.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+
+	restored := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+	games, err := restored.Restore(ctx)
+	if err != nil {
+		t.Fatalf("Restore() error = %v", err)
+	}
+	if len(games) != 1 {
+		t.Fatalf("Restore() = %d games, want 1", len(games))
+	}
+
+	rg := games[0]
+	if rg.ID() != g.ID() || rg.Status() != g.Status() || rg.Rule().ID != g.Rule().ID {
+		t.Errorf("Restore() game = %v, want %v", rg.ID(), g.ID())
+	}
+	if got, want := rg.Dealer().CardsInfo(), g.Dealer().CardsInfo(); got != want {
+		t.Errorf("dealer cards = %v, want %v", got, want)
+	}
+	if got, want := rg.FindPlayer(p.ID).CardsInfo(), g.FindPlayer(p.ID).CardsInfo(); got != want {
+		t.Errorf("player cards = %v, want %v", got, want)
+	}
+	if got := rg.FindPlayer(p.ID).BetAmount(); got != 50 {
+		t.Errorf("player bet = %v, want %v", got, 50)
+	}
+	if got, want := rg.shoe.Remaining(), g.shoe.Remaining(); got != want {
+		t.Errorf("shoe = %d cards, want %d", got, want)
+	}
+	if restored.CurrentGame(p.ID) != rg {
+		t.Errorf("CurrentGame() = %v, want %v", restored.CurrentGame(p.ID), rg)
+	}
+}
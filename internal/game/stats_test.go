@@ -0,0 +1,97 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_PlayerStats(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+
+	now := time.Now()
+	for i, r := range []model.Record{
+		{Reward: 20, Bet: 10, StartValue: 20},
+		{Reward: 10, Bet: 10, StartValue: 20},
+		{Reward: -30, Bet: 30, StartValue: 15},
+		{Reward: 0, Bet: 10, StartValue: 17},
+		{Reward: -10, Bet: 10, StartValue: 15},
+		{Reward: -20, Bet: 20, StartValue: 12},
+		{Reward: 50, Bet: 60, IsDealer: true, StartValue: 18},
+		{Reward: -40, Bet: 60, IsDealer: true, StartValue: 16},
+	} {
+		r.PlayerID = "2"
+		r.CreatedAt = now.Add(time.Duration(i-7) * 24 * time.Hour)
+		_ = store.SaveRecord(ctx, &r)
+	}
+
+	p := &model.Player{ID: "2"}
+	tests := []struct {
+		name string
+		from time.Time
+		role model.StatsRole
+		want []string
+	}{
+		{
+			name: "player",
+			role: model.StatsParticipant,
+			want: []string{
+				"Số ván: 6 (thắng 2, hoà 1, thua 3, tỉ lệ thắng 33%)", "Lãi/lỗ: -30☘️", "Thắng đậm nhất: 20☘️",
+				"Thua đậm nhất: -30☘️", "Cược trung bình: 15☘️", "Chuỗi hiện tại: thua 2 ván, dài nhất: thắng 2, thua 2",
+				"`20`: thắng 2/2 ván (100%)", "`15`: thắng 0/2 ván (0%)",
+			},
+		},
+		{
+			name: "dealer",
+			role: model.StatsDealer,
+			want: []string{"Số ván: 2 (thắng 1, hoà 0, thua 1, tỉ lệ thắng 50%)", "Lãi/lỗ: 10☘️", "Cược trung bình: 60☘️"},
+		},
+		{
+			name: "all",
+			role: model.StatsAll,
+			want: []string{"Số ván: 8", "Lãi/lỗ: -20☘️", "Chuỗi hiện tại: thua 1 ván", "Thắng đậm nhất: 50☘️"},
+		},
+		{
+			name: "last two days",
+			from: now.Add(-36 * time.Hour),
+			want: []string{"Số ván: 2", "Lãi/lỗ: 10☘️"},
+		},
+		{
+			name: "no games",
+			from: now.Add(time.Hour),
+			want: []string{"Chưa có ván nào"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := m.PlayerStats(ctx, p, tt.from, time.Time{}, tt.role)
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("PlayerStats() = %q, want to contain %q", got, s)
+				}
+			}
+		})
+	}
+}
+
+func TestManager_FinishGame_RecordStats(t *testing.T) {
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, ShoeConfig{Decks: 1, Shuffler: NewSeededShuffler(1)})
+	g := playGame(t, m)
+
+	for _, r := range store.results {
+		pg := g.FindPlayer(r.PlayerID)
+		want := pg.BetAmount()
+		if r.IsDealer {
+			want = 20
+		}
+		if r.Bet != want || r.StartValue != pg.Cards()[:2].Value(g.Rule()) {
+			t.Errorf("record of %s: bet = %d, start value = %d, want %d, %d", r.PlayerID, r.Bet, r.StartValue, want, pg.Cards()[:2].Value(g.Rule()))
+		}
+	}
+}
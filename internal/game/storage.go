@@ -2,18 +2,77 @@ package game
 
 import (
 	"context"
+	"time"
 
 	"github.com/psucodervn/verixilac/internal/model"
 )
 
 type Storage interface {
 	SaveRecord(ctx context.Context, r *model.Record) error
-	ListRecords(ctx context.Context, playerID string, limit int) ([]model.Record, error)
+	// ListRecords returns the records of the player created in [from, to), newest first
+	ListRecords(ctx context.Context, playerID string, from, to time.Time, cursor model.Cursor, limit int) ([]model.Record, error)
+	// Leaderboard returns the totals of every player since the day of from, or of all time when from is zero
+	Leaderboard(ctx context.Context, from time.Time) ([]model.PlayerTotals, error)
+	// AggregateRecords sums up the records of the player played in the role and created in [from, to)
+	AggregateRecords(ctx context.Context, playerID string, from, to time.Time, role model.StatsRole) (*model.PlayerStats, error)
+	SaveGameRecord(ctx context.Context, r *model.GameRecord) error
+	ListGameRecords(ctx context.Context, from, to time.Time) ([]model.GameRecord, error)
+	ListPlayerGameRecords(ctx context.Context, playerID string, from, to time.Time) ([]model.GameRecord, error)
+	// ListHeadToHeadRecords returns the finished games where one of the players dealt and the other bet, oldest first
+	ListHeadToHeadRecords(ctx context.Context, a, b string) ([]model.GameRecord, error)
 	GetPlayerByID(ctx context.Context, id string) (*model.Player, error)
 	SavePlayer(ctx context.Context, p *model.Player) error
 	ListPlayers(ctx context.Context) ([]model.Player, error)
 	ListActivePlayers(ctx context.Context) ([]model.Player, error)
-	AddPlayerBalance(ctx context.Context, id string, amount int64) (*model.Player, error)
+	// AddPlayerBalance posts a deposit, or a withdrawal when amount is negative, made by the operator
+	AddPlayerBalance(ctx context.Context, id string, amount int64, operatorID string) (*model.Player, error)
 	UpdatePlayerStatus(ctx context.Context, id string, status model.UserStatus) (*model.Player, error)
-	ResetBalance(ctx context.Context, newBalance int64) error
+	// ResetBalance closes the running season and returns it, then sets every balance to newBalance.
+	// It returns model.ErrUnsettledGames while the bets of a dealt game are escrowed.
+	ResetBalance(ctx context.Context, newBalance int64, operatorID string) (*model.Season, error)
+	CurrentSeason(ctx context.Context) (*model.Season, error)
+	GetSeason(ctx context.Context, number int) (*model.Season, error)
+	// Transfer moves amount between two players in one transaction, it returns model.ErrInsufficientBalance
+	// if the sender cannot afford it
+	Transfer(ctx context.Context, fromID, toID string, amount int64) (from, to *model.Player, err error)
+	// CreateLoan pays the amount of the loan from the lender to the borrower and saves the loan in one transaction
+	CreateLoan(ctx context.Context, l *model.Loan) error
+	// RepayLoans repays the open loans of the borrower oldest first, only those from the lender if it is not empty
+	// and only those repaid from winnings if auto is set. At most amount is repaid, everything owed when amount is 0.
+	// Auto repayments are also capped at the borrower's balance.
+	RepayLoans(ctx context.Context, borrowerID, lenderID string, amount int64, auto bool) ([]model.Repayment, error)
+	// ListLoans returns the open loans lent or borrowed by the player, oldest first
+	ListLoans(ctx context.Context, playerID string) ([]model.Loan, error)
+	// ListDueLoans returns the open loans due before the time, oldest due first
+	ListDueLoans(ctx context.Context, before time.Time) ([]model.Loan, error)
+	MarkLoanReminded(ctx context.Context, id string, at time.Time) error
+	// GetFaucet returns the faucet state of the player, an empty one if they never claimed
+	GetFaucet(ctx context.Context, playerID string) (*model.Faucet, error)
+	// UpdateFaucet runs fn on the faucet state and the balance of the player in one transaction, the state is saved
+	// and the positive amount returned by fn is granted from the house for the reason. Nothing is saved if fn fails.
+	UpdateFaucet(ctx context.Context, playerID string, reason model.LedgerReason, fn func(f *model.Faucet, balance int64) (int64, error)) (*model.Player, error)
+	ListLedgerEntries(ctx context.Context, account string, limit int) ([]model.LedgerEntry, error)
+	LedgerBalances(ctx context.Context) (map[string]int64, error)
+	// EscrowBets takes the bets from the players' balances, it does nothing if the game is already escrowed
+	EscrowBets(ctx context.Context, gameID string, bets map[string]uint64) error
+	// SettleGame returns the escrowed bets plus the rewards to the players, repays the loans marked to be repaid
+	// from winnings with the positive rewards and saves the records of the game, if any, in one transaction.
+	// It returns model.ErrAlreadySettled if the game is already settled.
+	SettleGame(ctx context.Context, gameID string, rewards map[string]int64, records []model.Record, gr *model.GameRecord) ([]model.Repayment, error)
+	// Follow returns model.ErrExists if the follower already follows the followee
+	Follow(ctx context.Context, followerID, followeeID string) error
+	// Unfollow returns model.ErrNotFound if the follower does not follow the followee
+	Unfollow(ctx context.Context, followerID, followeeID string) error
+	ListFollowees(ctx context.Context, followerID string) ([]model.Following, error)
+	ListFollowers(ctx context.Context, followeeID string) ([]model.Following, error)
+	SaveRoom(ctx context.Context, r *model.RoomState) error
+	DeleteRoom(ctx context.Context, id string) error
+	ListRooms(ctx context.Context) ([]model.RoomState, error)
+	SaveRule(ctx context.Context, r *model.RuleConfig) error
+	DeleteRule(ctx context.Context, id string) error
+	ListRules(ctx context.Context) ([]model.RuleConfig, error)
+	SaveGameProof(ctx context.Context, p *model.GameProof) error
+	GetGameProof(ctx context.Context, gameID string) (*model.GameProof, error)
+	SaveGameEvents(ctx context.Context, events []model.GameEvent) error
+	ListGameEvents(ctx context.Context, gameID string) ([]model.GameEvent, error)
 }
@@ -0,0 +1,153 @@
+package game
+
+import (
+	"sync"
+	"time"
+)
+
+// TurnWarning is how long before the turn timeout the current player is warned
+const TurnWarning = 15 * time.Second
+
+// turnTimers runs the turn timer of the playing games, a game has at most one running timer
+type turnTimers struct {
+	mu    sync.Mutex
+	turns map[string]*turnTimer // game id -> timer of the current turn
+}
+
+type turnTimer struct {
+	warn   *time.Timer
+	expire *time.Timer
+}
+
+func newTurnTimers() *turnTimers {
+	return &turnTimers{turns: make(map[string]*turnTimer)}
+}
+
+// start replaces the turn timer of the game, onWarn is called shortly before the deadline and onExpire at the deadline.
+// Callbacks of a replaced or stopped timer are never called.
+func (t *turnTimers) start(gameID string, timeout time.Duration, deadline time.Time, onWarn func(left time.Duration), onExpire func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopLocked(gameID)
+
+	tt := &turnTimer{}
+	warning := TurnWarning
+	if warning > timeout/2 {
+		warning = timeout / 2
+	}
+	if d := time.Until(deadline.Add(-warning)); d > 0 {
+		tt.warn = time.AfterFunc(d, func() {
+			if t.isCurrent(gameID, tt, false) {
+				onWarn(time.Until(deadline).Round(time.Second))
+			}
+		})
+	}
+	tt.expire = time.AfterFunc(time.Until(deadline), func() {
+		if t.isCurrent(gameID, tt, true) {
+			onExpire()
+		}
+	})
+	t.turns[gameID] = tt
+}
+
+// stop cancels the turn timer of the game
+func (t *turnTimers) stop(gameID string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.stopLocked(gameID)
+}
+
+func (t *turnTimers) stopLocked(gameID string) {
+	tt := t.turns[gameID]
+	if tt == nil {
+		return
+	}
+	if tt.warn != nil {
+		tt.warn.Stop()
+	}
+	tt.expire.Stop()
+	delete(t.turns, gameID)
+}
+
+// isCurrent checks if tt is still the timer of the game, done removes it
+func (t *turnTimers) isCurrent(gameID string, tt *turnTimer, done bool) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.turns[gameID] != tt {
+		return false
+	}
+	if done {
+		delete(t.turns, gameID)
+	}
+	return true
+}
+
+// BettingTick is how often the betting countdown is refreshed
+const BettingTick = 10 * time.Second
+
+// countdowns runs the betting countdown of the games which are waiting for bets
+type countdowns struct {
+	mu    sync.Mutex
+	stops map[string]chan struct{} // game id -> stop channel of the countdown
+}
+
+func newCountdowns() *countdowns {
+	return &countdowns{stops: make(map[string]chan struct{})}
+}
+
+// start replaces the countdown of the game, onTick is called every tick with the time left
+// and onExpire at the deadline. Callbacks of a replaced or stopped countdown are never called.
+func (c *countdowns) start(gameID string, deadline time.Time, tick time.Duration, onTick func(left time.Duration), onExpire func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopLocked(gameID)
+
+	stop := make(chan struct{})
+	c.stops[gameID] = stop
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		timer := time.NewTimer(time.Until(deadline))
+		defer timer.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+				if left := time.Until(deadline); left >= tick/2 {
+					onTick(left.Round(time.Second))
+				}
+			case <-timer.C:
+				if c.finish(gameID, stop) {
+					onExpire()
+				}
+				return
+			}
+		}
+	}()
+}
+
+// stop cancels the countdown of the game
+func (c *countdowns) stop(gameID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stopLocked(gameID)
+}
+
+func (c *countdowns) stopLocked(gameID string) {
+	if stop, ok := c.stops[gameID]; ok {
+		close(stop)
+		delete(c.stops, gameID)
+	}
+}
+
+// finish removes the countdown if stop is still the one of the game
+func (c *countdowns) finish(gameID string, stop chan struct{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.stops[gameID] != stop {
+		return false
+	}
+	delete(c.stops, gameID)
+	return true
+}
@@ -0,0 +1,156 @@
+package game
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestTurnTimers(t *testing.T) {
+	tt := newTurnTimers()
+	fired := make(chan string, 10)
+	start := func(name string, timeout time.Duration) {
+		tt.start("g", timeout, time.Now().Add(timeout), func(time.Duration) {
+			fired <- name + " warn"
+		}, func() {
+			fired <- name + " expire"
+		})
+	}
+
+	// a replaced timer never fires
+	start("a", 20*time.Millisecond)
+	start("b", 40*time.Millisecond)
+	for _, want := range []string{"b warn", "b expire"} {
+		select {
+		case got := <-fired:
+			if got != want {
+				t.Fatalf("fired %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%q did not fire", want)
+		}
+	}
+
+	start("c", 20*time.Millisecond)
+	tt.stop("g")
+	select {
+	case got := <-fired:
+		t.Fatalf("stopped timer fired %q", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestManager_TurnTimeout(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, 20*time.Millisecond, ShoeConfig{Decks: 1, Persist: true, Shuffler: NewSeededShuffler(2)})
+
+	var mu sync.Mutex
+	timedOut := map[string]bool{}
+	m.OnTurnTimeout(func(g *Game, pg *PlayerInGame, stood bool) {
+		mu.Lock()
+		defer mu.Unlock()
+		timedOut[pg.ID] = true
+	})
+	finished := make(chan *Game, 1)
+	m.OnGameFinish(func(g *Game) {
+		finished <- g
+	})
+
+	dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+	r, _ := m.CreateRoom(ctx, dealer)
+	g, _ := m.NewGame(dealer, "")
+	for _, id := range []string{"2", "3"} {
+		p := &model.Player{ID: id, Name: "Player #" + id, Balance: 1000}
+		_, _ = m.JoinRoom(ctx, p, r.ID())
+		if err := m.PlayerBet(ctx, g.ID(), p, 10); err != nil {
+			t.Fatalf("PlayerBet() error = %v", err)
+		}
+	}
+	if _, err := m.Deal(ctx, g.ID()); err != nil {
+		t.Fatalf("Deal() error = %v", err)
+	}
+	if err := m.Start(ctx, g); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	if g.Finished() {
+		t.Fatalf("game finished at start, pick another seed")
+	}
+
+	// nobody plays, the timers play for them
+	select {
+	case <-finished:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("game did not finish, status = %v", g.Status())
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	for _, pg := range g.AllPlayers() {
+		if !pg.IsDone() && !pg.IsDealer() {
+			t.Errorf("%s is not done", pg.Name)
+		}
+		if !timedOut[pg.ID] && pg.ResultType() != model.TypeBlackJack && pg.ResultType() != model.TypeDoubleBlackJack {
+			t.Errorf("%s did not time out", pg.Name)
+		}
+	}
+	if _, ok := m.turns.turns[g.ID()]; ok {
+		t.Errorf("turn timer of the finished game is still running")
+	}
+}
+
+func TestManager_BettingWindow(t *testing.T) {
+	tests := []struct {
+		name      string
+		bets      []string
+		wantDealt bool
+	}{
+		{name: "no bets", wantDealt: false},
+		{name: "dealt", bets: []string{"2"}, wantDealt: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			m := NewManager(newFakeStore(), 100, 0, time.Minute, DefaultShoeConfig)
+			m.SetBettingWindow(50 * time.Millisecond)
+			expired := make(chan bool, 1)
+			m.OnBettingTimeout(func(g *Game, dealt bool) {
+				expired <- dealt
+			})
+
+			dealer := &model.Player{ID: "1", Name: "Player #1", Balance: 1000}
+			r, _ := m.CreateRoom(ctx, dealer)
+			g, err := m.NewGame(dealer, "")
+			if err != nil {
+				t.Fatalf("NewGame() error = %v", err)
+			}
+			if g.DealAt().IsZero() {
+				t.Fatalf("DealAt() is zero, want the end of the betting window")
+			}
+			for _, id := range tt.bets {
+				p := &model.Player{ID: id, Name: "Player #" + id, Balance: 1000}
+				_, _ = m.JoinRoom(ctx, p, r.ID())
+				if err := m.PlayerBet(ctx, g.ID(), p, 10); err != nil {
+					t.Fatalf("PlayerBet() error = %v", err)
+				}
+			}
+
+			select {
+			case dealt := <-expired:
+				if dealt != tt.wantDealt {
+					t.Errorf("dealt = %v, want %v", dealt, tt.wantDealt)
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("betting window did not expire")
+			}
+			if tt.wantDealt && g.Status() == Betting {
+				t.Errorf("game was not dealt")
+			}
+			if !tt.wantDealt && r.Game() != nil {
+				t.Errorf("game was not cancelled")
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_Leaderboard(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+
+	now := time.Now()
+	lastWeek := now.AddDate(0, 0, -7)
+	for _, r := range []model.Record{
+		// 1: 6 games, +60, wins 4
+		{PlayerID: "1", Reward: 20}, {PlayerID: "1", Reward: 20}, {PlayerID: "1", Reward: 20},
+		{PlayerID: "1", Reward: 20}, {PlayerID: "1", Reward: -10}, {PlayerID: "1", Reward: -10},
+		// 2: 2 games today, +100, and a big loss last week
+		{PlayerID: "2", Reward: 50}, {PlayerID: "2", Reward: 50}, {PlayerID: "2", Reward: -500, CreatedAt: lastWeek},
+		// 3: 7 games, -10, wins 5
+		{PlayerID: "3", Reward: 10}, {PlayerID: "3", Reward: 10}, {PlayerID: "3", Reward: 10}, {PlayerID: "3", Reward: 10},
+		{PlayerID: "3", Reward: 10}, {PlayerID: "3", Reward: -30}, {PlayerID: "3", Reward: -30},
+	} {
+		if r.CreatedAt.IsZero() {
+			r.CreatedAt = now
+		}
+		_ = store.SaveRecord(ctx, &r)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	tests := []struct {
+		name  string
+		from  time.Time
+		order TopOrder
+		want  []string
+	}{
+		{
+			name:  "profit today",
+			from:  today,
+			order: TopProfit,
+			want:  []string{"1. `Player #2`: 100☘️, 2 ván", "2. `Player #1`: 60☘️", "3. `Player #3`: -10☘️"},
+		},
+		{
+			name:  "profit all time",
+			order: TopProfit,
+			want:  []string{"1. `Player #1`", "2. `Player #3`", "3. `Player #2`: -400☘️, 3 ván"},
+		},
+		{
+			name:  "games",
+			from:  today,
+			order: TopGames,
+			want:  []string{"1. `Player #3`: -10☘️, 7 ván", "2. `Player #1`", "3. `Player #2`"},
+		},
+		{
+			name:  "win rate needs enough games",
+			order: TopWinRate,
+			want:  []string{"1. `Player #3`: -10☘️, 7 ván, thắng 71%", "2. `Player #1`: 60☘️, 6 ván, thắng 67%"},
+		},
+		{
+			name:  "nobody",
+			from:  now.AddDate(0, 0, 1),
+			order: TopProfit,
+			want:  []string{"Chưa có ai"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.Leaderboard(ctx, tt.from, tt.order)
+			if err != nil {
+				t.Fatalf("Leaderboard() error = %v", err)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("Leaderboard() = %q, want to contain %q", got, s)
+				}
+			}
+			if tt.order == TopWinRate && strings.Contains(got, "Player #2") {
+				t.Errorf("Leaderboard() = %q, want no player with less than %d games", got, TopMinGames)
+			}
+		})
+	}
+}
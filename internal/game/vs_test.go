@@ -0,0 +1,97 @@
+package game
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestManager_HeadToHead(t *testing.T) {
+	ctx := context.Background()
+	store := newFakeStore()
+	m := NewManager(store, 100, 0, time.Minute, DefaultShoeConfig)
+
+	hand := func(id string, dealer bool, reward int64, typ model.ResultType, value int) model.GameRecordPlayer {
+		return model.GameRecordPlayer{PlayerID: id, IsDealer: dealer, Bet: 10, Reward: reward, ResultType: typ, Value: value}
+	}
+	now := time.Now()
+	for i, r := range []model.GameRecord{
+		// 1 deals, 2 wins with a blackjack, 3 loses
+		{DealerID: "1", Participants: []model.GameRecordPlayer{
+			hand("1", true, -10, model.TypeNormal, 18), hand("2", false, 20, model.TypeBlackJack, 21), hand("3", false, -10, model.TypeBusted, 24),
+		}},
+		// 2 deals, 1 loses
+		{DealerID: "2", Participants: []model.GameRecordPlayer{
+			hand("2", true, 10, model.TypeNormal, 19), hand("1", false, -10, model.TypeNormal, 17),
+		}},
+		// 3 deals, 1 and 2 only bet against 3
+		{DealerID: "3", Participants: []model.GameRecordPlayer{
+			hand("3", true, 0, model.TypeNormal, 18), hand("1", false, 10, model.TypeNormal, 20), hand("2", false, -10, model.TypeNormal, 16),
+		}},
+		// cancelled
+		{DealerID: "1", Status: model.GameCancelled, Participants: []model.GameRecordPlayer{
+			hand("1", true, 0, model.TypeNormal, 0), hand("2", false, 0, model.TypeNormal, 0),
+		}},
+	} {
+		r.ID = string(rune('a' + i))
+		r.EndedAt = now.Add(time.Duration(i) * time.Minute)
+		for _, rp := range r.Participants {
+			r.PlayerIDs = append(r.PlayerIDs, rp.PlayerID)
+		}
+		_ = store.SaveGameRecord(ctx, &r)
+	}
+
+	p1 := &model.Player{ID: "1", Name: "Player #1"}
+	p2 := &model.Player{ID: "2", Name: "Player #2"}
+	tests := []struct {
+		name     string
+		p        *model.Player
+		opponent *model.Player
+		want     []string
+		wantErr  error
+	}{
+		{
+			name:     "from dealer",
+			p:        p1,
+			opponent: p2,
+			want: []string{
+				"2 ván", "`Player #1` làm cái 1 ván, `Player #2` làm cái 1 ván", "`Player #1` thắng 0, hoà 0, thua 2",
+				"Tiền về `Player #1`: -30☘️", "`Xì lác`: `Player #1` 0, `Player #2` 1", "`Player #2` làm cái: 17 điểm - 19 điểm, -10☘️",
+			},
+		},
+		{
+			name:     "from opponent",
+			p:        p2,
+			opponent: p1,
+			want:     []string{"`Player #2` thắng 2, hoà 0, thua 0", "Tiền về `Player #2`: 30☘️", "`Player #1` làm cái: Xì lác - 18 điểm, 20☘️"},
+		},
+		{
+			name:     "no encounters",
+			p:        p1,
+			opponent: &model.Player{ID: "4", Name: "Player #4"},
+			want:     []string{"0 ván"},
+		},
+		{
+			name:     "yourself",
+			p:        p1,
+			opponent: p1,
+			wantErr:  ErrPlayYourself,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := m.HeadToHead(ctx, tt.p, tt.opponent)
+			if err != tt.wantErr {
+				t.Fatalf("HeadToHead() error = %v, want %v", err, tt.wantErr)
+			}
+			for _, s := range tt.want {
+				if !strings.Contains(got, s) {
+					t.Errorf("HeadToHead() = %q, want to contain %q", got, s)
+				}
+			}
+		})
+	}
+}
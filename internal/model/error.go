@@ -6,7 +6,14 @@ import (
 	"github.com/timshannon/badgerhold/v4"
 )
 
-var ErrNotFound = errors.New("not found")
+var (
+	ErrNotFound            = errors.New("not found")
+	ErrExists              = errors.New("already exists")
+	ErrInsufficientBalance = errors.New("không đủ số dư")
+	ErrUnbalancedEntry     = errors.New("bút toán không cân")
+	ErrAlreadySettled      = errors.New("ván đã được thanh toán")
+	ErrUnsettledGames      = errors.New("còn ván đã chia bài chưa kết thúc, hãy kết thúc hoặc huỷ trước")
+)
 
 func IsNotFound(err error) bool {
 	return errors.Is(err, ErrNotFound) || errors.Is(err, badgerhold.ErrNotFound)
@@ -3,6 +3,7 @@ package model
 import (
 	"os"
 	"strings"
+	"time"
 )
 
 type (
@@ -30,6 +31,52 @@ type (
 		ResultType ResultType
 		Value      int
 		IsDealer   bool
+		Bet        uint64    // bet of the participant, total bets covered by the dealer
+		StartValue int       // value of the first two cards
+		CreatedAt  time.Time `badgerhold:"index"`
+	}
+
+	// Cursor pages through records newest first by their key, Before lists the records older than
+	// the key and After the newer ones, both zero start from the newest record
+	Cursor struct {
+		Before uint64
+		After  uint64
+	}
+
+	// Escrow holds the bets of a game from the deal until it is settled
+	Escrow struct {
+		GameID    string `badgerhold:"key"`
+		Holds     []EscrowHold
+		Settled   bool
+		CreatedAt time.Time
+		SettledAt time.Time
+	}
+
+	EscrowHold struct {
+		PlayerID string
+		Amount   uint64
+	}
+
+	// GameRecord is a finished or cancelled game
+	GameRecord struct {
+		ID           string `badgerhold:"key"`
+		RoomID       string
+		RuleID       string
+		DealerID     string
+		PlayerIDs    []string // dealer and participants
+		Participants []GameRecordPlayer
+		Status       GameStatus
+		StartedAt    time.Time
+		EndedAt      time.Time `badgerhold:"index"`
+	}
+
+	GameRecordPlayer struct {
+		PlayerID   string
+		IsDealer   bool
+		Bet        uint64
+		Reward     int64
+		ResultType ResultType
+		Value      int
 	}
 
 	Player struct {
@@ -39,6 +86,7 @@ type (
 		UserRole   UserRole
 		UserStatus UserStatus
 		Balance    int64
+		RuleID     string // preferred rule when dealing
 	}
 
 	Following struct {
@@ -46,6 +94,155 @@ type (
 		FollowerID string `badgerhold:"index"`
 		FolloweeID string `badgerhold:"index"`
 	}
+
+	// RoomState is a snapshot of a room and its running game, used to restore them after a restart
+	RoomState struct {
+		ID      string `badgerhold:"key"`
+		Creator string
+		MaxBet  uint64
+		Timeout time.Duration
+		Window  time.Duration // betting window
+		RuleID  string
+		Members []string
+		Shoe    *ShoeState // only when the shoe is kept between games
+		Game    *GameState
+	}
+
+	GameState struct {
+		ID          string
+		RuleID      string
+		Status      uint32
+		CurrentIdx  int
+		MaxBet      uint64
+		Timeout     time.Duration
+		CreatedAt   time.Time
+		Shoe        *ShoeState
+		Seed        []byte
+		ShoeOffset  int
+		EventSeq    int
+		ServerSeed  []byte
+		ClientSeeds []ClientSeed
+		Dealer      PlayerState
+		Players     []PlayerState
+	}
+
+	// RuleConfig is a rule added or edited by admins, Data is the rule in JSON
+	RuleConfig struct {
+		ID        string `badgerhold:"key"`
+		Data      []byte
+		UpdatedBy string
+		UpdatedAt time.Time
+	}
+
+	// GameEvent is an action in a game, the game can be rebuilt by applying its events in order
+	GameEvent struct {
+		ID        uint64 `badgerhold:"key"`
+		GameID    string `badgerhold:"index"`
+		Seq       int
+		Type      EventType
+		PlayerID  string
+		RuleID    string // new: the rule of the game
+		Cards     []int  // deal, hit
+		Amount    int64  // bet: the bet amount, compare: the dealer's reward
+		CreatedAt time.Time
+	}
+
+	ClientSeed struct {
+		PlayerID string
+		Seed     string
+	}
+
+	// GameProof holds what is needed to verify the shuffle of a game, it is saved when the game ends
+	GameProof struct {
+		GameID      string `badgerhold:"key"`
+		Commitment  string
+		ServerSeed  []byte
+		ClientSeeds []ClientSeed
+		Decks       int
+		CreatedAt   time.Time
+	}
+
+	// Season runs between two balance resets, closing it keeps the final standings of the players
+	Season struct {
+		Number    int   `badgerhold:"key"`
+		Balance   int64 // starting balance, 0 for the first season
+		StartedAt time.Time
+		EndedAt   time.Time
+		Closed    bool `badgerhold:"index"`
+		ClosedBy  string
+		Standings []SeasonStanding // ranked by the final balance
+	}
+
+	SeasonStanding struct {
+		Rank     int
+		PlayerID string
+		Name     string
+		Balance  int64
+		Games    int
+		Wins     int
+		Net      int64
+	}
+
+	// LedgerEntry is one side of a balance movement, the entries of a movement share TxID and sum to zero.
+	// Entries are never updated or deleted, the balance of an account is the sum of its entries.
+	LedgerEntry struct {
+		ID        uint64 `badgerhold:"key"`
+		TxID      string
+		Account   string `badgerhold:"index"` // player ID, AccountEscrow or AccountHouse
+		Amount    int64
+		Reason    LedgerReason
+		Reference string // game ID for bet and payout, operator ID for deposit, withdrawal, reset and transfer, loan ID for loan and repayment
+		CreatedAt time.Time
+	}
+
+	// Loan is money lent by a player to another, it is closed once fully repaid
+	Loan struct {
+		ID         string `badgerhold:"key"`
+		LenderID   string `badgerhold:"index"`
+		BorrowerID string `badgerhold:"index"`
+		Amount     int64
+		Repaid     int64
+		AutoRepay  bool      // repaid from the borrower's winnings
+		DueAt      time.Time // zero for no due date
+		RemindedAt time.Time
+		Closed     bool `badgerhold:"index"`
+		CreatedAt  time.Time
+		ClosedAt   time.Time
+	}
+
+	// Repayment is the part of a loan repaid at once
+	Repayment struct {
+		Loan   Loan
+		Amount int64
+	}
+
+	// Faucet keeps the daily bonus and relief claims of a player, they limit how often free money is granted
+	Faucet struct {
+		PlayerID   string `badgerhold:"key"`
+		LastDaily  time.Time
+		Streak     int         // consecutive days the daily bonus was claimed
+		BrokeSince time.Time   // since when the balance has been under the relief threshold, zero if it is not
+		Reliefs    []time.Time // reliefs granted, the old ones are dropped
+		LastGaveAt time.Time   // last transfer or loan to another player
+		Blocked    bool        // by an admin, from both the daily bonus and the relief
+	}
+
+	ShoeState struct {
+		Decks       int
+		Penetration float64
+		Seed        []byte
+		Cards       []int
+		Pos         int
+	}
+
+	PlayerState struct {
+		PlayerID string
+		Cards    []int
+		Bet      uint64
+		Status   uint32
+		Reward   int64
+		LastHit  int64
+	}
 )
 
 var (
@@ -80,6 +277,16 @@ func (p Player) IsActive() bool {
 	return p.UserStatus == UserStatusActive
 }
 
+// Outstanding is the amount left to repay
+func (l Loan) Outstanding() int64 {
+	return l.Amount - l.Repaid
+}
+
+// Overdue checks if the loan is still open after its due date
+func (l Loan) Overdue(now time.Time) bool {
+	return !l.Closed && !l.DueAt.IsZero() && now.After(l.DueAt)
+}
+
 func (st UserStatus) String() string {
 	switch st {
 	case UserStatusActive:
@@ -91,6 +298,83 @@ func (st UserStatus) String() string {
 	}
 }
 
+type GameStatus uint8
+
+const (
+	GameFinished GameStatus = iota
+	GameCancelled
+)
+
+func (s GameStatus) String() string {
+	if s == GameCancelled {
+		return "Đã huỷ"
+	}
+	return "Đã kết thúc"
+}
+
+type EventType string
+
+const (
+	EventNew     EventType = "new"
+	EventBet     EventType = "bet"
+	EventLeave   EventType = "leave"
+	EventDeal    EventType = "deal"
+	EventHit     EventType = "hit"
+	EventStand   EventType = "stand"
+	EventPass    EventType = "pass"
+	EventCompare EventType = "compare"
+	EventFinish  EventType = "finish"
+	EventCancel  EventType = "cancel"
+)
+
+type LedgerReason string
+
+const (
+	LedgerBet        LedgerReason = "bet"
+	LedgerPayout     LedgerReason = "payout"
+	LedgerDeposit    LedgerReason = "deposit"
+	LedgerWithdrawal LedgerReason = "withdrawal"
+	LedgerReset      LedgerReason = "reset"
+	LedgerTransfer   LedgerReason = "transfer"
+	LedgerLoan       LedgerReason = "loan"
+	LedgerRepayment  LedgerReason = "repayment"
+	LedgerDaily      LedgerReason = "daily"
+	LedgerRelief     LedgerReason = "relief"
+)
+
+func (r LedgerReason) String() string {
+	switch r {
+	case LedgerBet:
+		return "Cược"
+	case LedgerPayout:
+		return "Trả thưởng"
+	case LedgerDeposit:
+		return "Nạp"
+	case LedgerWithdrawal:
+		return "Rút"
+	case LedgerReset:
+		return "Reset"
+	case LedgerTransfer:
+		return "Chuyển khoản"
+	case LedgerLoan:
+		return "Cho vay"
+	case LedgerRepayment:
+		return "Trả nợ"
+	case LedgerDaily:
+		return "Quà hằng ngày"
+	case LedgerRelief:
+		return "Cứu trợ"
+	default:
+		return string(r)
+	}
+}
+
+// accounts of the ledger which are not players
+const (
+	AccountEscrow = "@escrow" // bets held until the game is settled
+	AccountHouse  = "@house"  // counterpart of deposits, withdrawals, resets and faucet grants
+)
+
 type ResultType uint8
 
 const (
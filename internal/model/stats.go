@@ -0,0 +1,163 @@
+package model
+
+import "time"
+
+// StatsRole selects the games of a player by the role played
+type StatsRole uint8
+
+const (
+	StatsAll StatsRole = iota
+	StatsDealer
+	StatsParticipant
+)
+
+func (r StatsRole) String() string {
+	switch r {
+	case StatsDealer:
+		return "làm cái"
+	case StatsParticipant:
+		return "chơi con"
+	default:
+		return "tất cả"
+	}
+}
+
+// Match checks if the record was played in the role
+func (r StatsRole) Match(rec *Record) bool {
+	return r == StatsAll || (r == StatsDealer) == rec.IsDealer
+}
+
+// PlayerStats aggregates the records of a player, records must be added oldest first
+type PlayerStats struct {
+	Games       int
+	Wins        int
+	Draws       int
+	Losses      int
+	Net         int64
+	BiggestWin  int64
+	BiggestLoss int64 // negative
+	TotalBet    uint64
+	BetGames    int // games with a known bet, older records have none
+
+	// by the value of the first two cards, older records have none
+	StartValues map[int]*WinRate
+
+	Streak        int // current streak, positive for wins and negative for losses, a draw ends it
+	LongestWins   int
+	LongestLosses int
+}
+
+type WinRate struct {
+	Games int
+	Wins  int
+}
+
+func NewPlayerStats() *PlayerStats {
+	return &PlayerStats{StartValues: map[int]*WinRate{}}
+}
+
+func (s *PlayerStats) Add(r *Record) {
+	s.Games++
+	s.Net += r.Reward
+	if r.Bet > 0 {
+		s.TotalBet += r.Bet
+		s.BetGames++
+	}
+	if r.StartValue > 0 {
+		if s.StartValues[r.StartValue] == nil {
+			s.StartValues[r.StartValue] = &WinRate{}
+		}
+		s.StartValues[r.StartValue].Games++
+		if r.Reward > 0 {
+			s.StartValues[r.StartValue].Wins++
+		}
+	}
+
+	switch {
+	case r.Reward > 0:
+		s.Wins++
+		if r.Reward > s.BiggestWin {
+			s.BiggestWin = r.Reward
+		}
+		if s.Streak < 0 {
+			s.Streak = 0
+		}
+		s.Streak++
+		if s.Streak > s.LongestWins {
+			s.LongestWins = s.Streak
+		}
+	case r.Reward < 0:
+		s.Losses++
+		if r.Reward < s.BiggestLoss {
+			s.BiggestLoss = r.Reward
+		}
+		if s.Streak > 0 {
+			s.Streak = 0
+		}
+		s.Streak--
+		if -s.Streak > s.LongestLosses {
+			s.LongestLosses = -s.Streak
+		}
+	default:
+		s.Draws++
+		s.Streak = 0
+	}
+}
+
+// WinRate is the percentage of games won
+func (s *PlayerStats) WinRate() float64 {
+	if s.Games == 0 {
+		return 0
+	}
+	return float64(s.Wins) * 100 / float64(s.Games)
+}
+
+func (s *PlayerStats) AverageBet() uint64 {
+	if s.BetGames == 0 {
+		return 0
+	}
+	return s.TotalBet / uint64(s.BetGames)
+}
+
+// PlayerTotals is the rolling aggregate of the games of a player in a day, or of all time when Day is empty.
+// They are updated with every record so leaderboards do not rescan the records.
+type PlayerTotals struct {
+	ID       string `badgerhold:"key"` // day/player
+	Day      string `badgerhold:"index"`
+	PlayerID string
+	Games    int
+	Wins     int
+	Net      int64
+}
+
+// TotalsDay is the day of the totals the record is added to
+func TotalsDay(t time.Time) string {
+	return t.Format("2006-01-02")
+}
+
+func TotalsID(day, playerID string) string {
+	return day + "/" + playerID
+}
+
+func (t *PlayerTotals) Add(r *Record) {
+	t.Games++
+	t.Net += r.Reward
+	if r.Reward > 0 {
+		t.Wins++
+	}
+}
+
+// Merge adds the totals of another day of the same player
+func (t *PlayerTotals) Merge(o *PlayerTotals) {
+	t.Games += o.Games
+	t.Wins += o.Wins
+	t.Net += o.Net
+}
+
+// WinRate is the percentage of games won
+func (t *PlayerTotals) WinRate() float64 {
+	if t.Games == 0 {
+		return 0
+	}
+	return float64(t.Wins) * 100 / float64(t.Games)
+}
@@ -2,7 +2,13 @@ package storage
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
 
+	"github.com/dgraph-io/badger/v4"
 	"github.com/timshannon/badgerhold/v4"
 
 	"github.com/psucodervn/verixilac/internal/model"
@@ -12,13 +18,37 @@ type BadgerHoldStorage struct {
 	store *badgerhold.Store
 }
 
-func (b *BadgerHoldStorage) ResetBalance(ctx context.Context, newBalance int64) error {
-	err := b.store.UpdateMatching(&model.Player{}, nil, func(record interface{}) error {
-		p := record.(*model.Player)
-		p.Balance = newBalance
-		return nil
+// ResetBalance closes the running season with the current balances, then sets every balance to newBalance.
+// Escrowed bets would be left over from the closed season, so it fails while a game is not settled.
+func (b *BadgerHoldStorage) ResetBalance(ctx context.Context, newBalance int64, operatorID string) (*model.Season, error) {
+	var season *model.Season
+	err := b.update(func(tx *badger.Txn) error {
+		cnt, err := b.store.TxCount(tx, &model.Escrow{}, badgerhold.Where("Settled").Eq(false))
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return model.ErrUnsettledGames
+		}
+		var players []model.Player
+		if err := b.store.TxFind(tx, &players, nil); err != nil {
+			return err
+		}
+		if season, err = b.closeSeason(tx, players, newBalance, operatorID); err != nil {
+			return err
+		}
+		legs := make(map[string]int64)
+		for _, p := range players {
+			legs[p.ID] = newBalance - p.Balance
+			legs[model.AccountHouse] -= newBalance - p.Balance
+			p.Balance = newBalance
+			if err := b.store.TxUpdate(tx, p.ID, &p); err != nil {
+				return err
+			}
+		}
+		return b.post(tx, model.LedgerReset, operatorID, legs)
 	})
-	return err
+	return season, err
 }
 
 func (b *BadgerHoldStorage) UpdatePlayerStatus(ctx context.Context, id string, status model.UserStatus) (*model.Player, error) {
@@ -34,17 +64,27 @@ func (b *BadgerHoldStorage) UpdatePlayerStatus(ctx context.Context, id string, s
 	return p, err
 }
 
-func (b *BadgerHoldStorage) AddPlayerBalance(ctx context.Context, id string, amount int64) (*model.Player, error) {
-	p := (*model.Player)(nil)
-	err := b.store.UpdateMatching(&model.Player{}, badgerhold.Where("ID").Eq(id), func(record interface{}) error {
-		p = record.(*model.Player)
+func (b *BadgerHoldStorage) AddPlayerBalance(ctx context.Context, id string, amount int64, operatorID string) (*model.Player, error) {
+	var p model.Player
+	err := b.update(func(tx *badger.Txn) error {
+		p = model.Player{}
+		if err := b.store.TxGet(tx, id, &p); err != nil {
+			return err
+		}
 		p.Balance += amount
-		return nil
+		if err := b.store.TxUpdate(tx, id, &p); err != nil {
+			return err
+		}
+		reason := model.LedgerDeposit
+		if amount < 0 {
+			reason = model.LedgerWithdrawal
+		}
+		return b.post(tx, reason, operatorID, map[string]int64{id: amount, model.AccountHouse: -amount})
 	})
-	if p == nil {
-		return nil, badgerhold.ErrNotFound
+	if err != nil {
+		return nil, err
 	}
-	return p, err
+	return &p, nil
 }
 
 func (b *BadgerHoldStorage) ListPlayers(ctx context.Context) ([]model.Player, error) {
@@ -60,11 +100,26 @@ func (b *BadgerHoldStorage) ListActivePlayers(ctx context.Context) ([]model.Play
 	return players, err
 }
 
+// SavePlayer keeps the stored balance of an existing player, balances only change through the ledger.
+// The balance of a new player is posted as a deposit.
 func (b *BadgerHoldStorage) SavePlayer(ctx context.Context, p *model.Player) error {
 	if len(p.ID) == 0 {
 		p.ID = p.TelegramID
 	}
-	return b.store.Upsert(p.ID, p)
+	return b.update(func(tx *badger.Txn) error {
+		var old model.Player
+		err := b.store.TxGet(tx, p.ID, &old)
+		if err == nil {
+			p.Balance = old.Balance
+			return b.store.TxUpdate(tx, p.ID, p)
+		} else if !model.IsNotFound(err) {
+			return err
+		}
+		if err := b.store.TxInsert(tx, p.ID, p); err != nil {
+			return err
+		}
+		return b.post(tx, model.LedgerDeposit, "", map[string]int64{p.ID: p.Balance, model.AccountHouse: -p.Balance})
+	})
 }
 
 func NewBadgerHoldStorage(dir string) *BadgerHoldStorage {
@@ -82,22 +137,292 @@ func NewBadgerHoldStorage(dir string) *BadgerHoldStorage {
 	}
 }
 
+// maxConflictRetries is how many times a transaction runs when it conflicts with a concurrent one
+const maxConflictRetries = 5
+
+// update runs fn in a read-write transaction and runs it again when the commit conflicts with a concurrent
+// transaction, fn must not keep state between runs
+func (b *BadgerHoldStorage) update(fn func(tx *badger.Txn) error) error {
+	var err error
+	for i := 0; i < maxConflictRetries; i++ {
+		if err = b.store.Badger().Update(fn); !errors.Is(err, badger.ErrConflict) {
+			return err
+		}
+	}
+	return err
+}
+
 func (b *BadgerHoldStorage) Close() error {
 	return b.store.Close()
 }
 
+// SaveRecord inserts the record and adds it to the player's totals of the day and of all time
 func (b *BadgerHoldStorage) SaveRecord(ctx context.Context, r *model.Record) error {
-	return b.store.Insert(badgerhold.NextSequence(), r)
+	return b.update(func(tx *badger.Txn) error {
+		if err := b.store.TxInsert(tx, badgerhold.NextSequence(), r); err != nil {
+			return err
+		}
+		return b.addTotals(tx, r)
+	})
 }
 
-func (b *BadgerHoldStorage) ListRecords(ctx context.Context, playerID string, limit int) ([]model.Record, error) {
+// ListRecords returns the latest records of the player, zero from and to mean no limit.
+// Sequence keys are not stored in the records, so they are sorted by time and paged by key.
+func (b *BadgerHoldStorage) ListRecords(ctx context.Context, playerID string, from, to time.Time, cursor model.Cursor, limit int) ([]model.Record, error) {
 	var records []model.Record
-	err := b.store.Find(&records, badgerhold.Where("PlayerID").Eq(playerID).SortBy("GameID").Limit(limit).Reverse())
+	q := inRange(badgerhold.Where("PlayerID").Eq(playerID).Index("PlayerID").And("CreatedAt"), "CreatedAt", from, to)
+	if cursor.After > 0 {
+		err := b.store.Find(&records, q.And(badgerhold.Key).Gt(cursor.After).SortBy("CreatedAt", "GameID").Limit(limit))
+		slices.Reverse(records)
+		return records, err
+	}
+	if cursor.Before > 0 {
+		q = q.And(badgerhold.Key).Lt(cursor.Before)
+	}
+	err := b.store.Find(&records, q.SortBy("CreatedAt", "GameID").Limit(limit).Reverse())
 	return records, err
 }
 
+// AggregateRecords streams the records of the player from their index into the stats, oldest first
+func (b *BadgerHoldStorage) AggregateRecords(ctx context.Context, playerID string, from, to time.Time, role model.StatsRole) (*model.PlayerStats, error) {
+	stats := model.NewPlayerStats()
+	q := inRange(badgerhold.Where("PlayerID").Eq(playerID).Index("PlayerID").And("CreatedAt"), "CreatedAt", from, to)
+	if role != model.StatsAll {
+		q = q.And("IsDealer").Eq(role == model.StatsDealer)
+	}
+	err := b.store.ForEach(q.SortBy("CreatedAt", "GameID"), func(r *model.Record) error {
+		stats.Add(r)
+		return nil
+	})
+	return stats, err
+}
+
+func (b *BadgerHoldStorage) SaveGameRecord(ctx context.Context, r *model.GameRecord) error {
+	return b.store.Upsert(r.ID, r)
+}
+
+// ListGameRecords returns the games ended in [from, to), zero from and to mean no limit
+func (b *BadgerHoldStorage) ListGameRecords(ctx context.Context, from, to time.Time) ([]model.GameRecord, error) {
+	var records []model.GameRecord
+	q := inRange(badgerhold.Where("EndedAt"), "EndedAt", from, to)
+	err := b.store.Find(&records, q.Index("EndedAt").SortBy("EndedAt"))
+	return records, err
+}
+
+// ListPlayerGameRecords returns the games the player dealt or joined which ended in [from, to)
+func (b *BadgerHoldStorage) ListPlayerGameRecords(ctx context.Context, playerID string, from, to time.Time) ([]model.GameRecord, error) {
+	var records []model.GameRecord
+	q := inRange(badgerhold.Where("PlayerIDs").Contains(playerID).And("EndedAt"), "EndedAt", from, to)
+	err := b.store.Find(&records, q.SortBy("EndedAt"))
+	return records, err
+}
+
+func (b *BadgerHoldStorage) ListHeadToHeadRecords(ctx context.Context, p1, p2 string) ([]model.GameRecord, error) {
+	var records []model.GameRecord
+	q := badgerhold.Where("PlayerIDs").Contains(p1).And("PlayerIDs").Contains(p2).
+		And("DealerID").In(p1, p2).And("Status").Eq(model.GameFinished)
+	err := b.store.Find(&records, q.SortBy("EndedAt"))
+	return records, err
+}
+
+// inRange limits the field of the query to [from, to)
+func inRange(c *badgerhold.Criterion, field string, from, to time.Time) *badgerhold.Query {
+	q := c.Ge(from)
+	if !to.IsZero() {
+		q = q.And(field).Lt(to)
+	}
+	return q
+}
+
 func (b *BadgerHoldStorage) GetPlayerByID(ctx context.Context, id string) (*model.Player, error) {
 	var p model.Player
 	err := b.store.Get(id, &p)
 	return &p, err
 }
+
+func (b *BadgerHoldStorage) SaveRoom(ctx context.Context, r *model.RoomState) error {
+	return b.store.Upsert(r.ID, r)
+}
+
+func (b *BadgerHoldStorage) DeleteRoom(ctx context.Context, id string) error {
+	err := b.store.Delete(id, &model.RoomState{})
+	if model.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (b *BadgerHoldStorage) ListRooms(ctx context.Context) ([]model.RoomState, error) {
+	var rooms []model.RoomState
+	err := b.store.Find(&rooms, nil)
+	return rooms, err
+}
+
+func (b *BadgerHoldStorage) SaveRule(ctx context.Context, r *model.RuleConfig) error {
+	return b.store.Upsert(r.ID, r)
+}
+
+func (b *BadgerHoldStorage) DeleteRule(ctx context.Context, id string) error {
+	err := b.store.Delete(id, &model.RuleConfig{})
+	if model.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+func (b *BadgerHoldStorage) ListRules(ctx context.Context) ([]model.RuleConfig, error) {
+	var rules []model.RuleConfig
+	err := b.store.Find(&rules, nil)
+	return rules, err
+}
+
+func (b *BadgerHoldStorage) SaveGameProof(ctx context.Context, p *model.GameProof) error {
+	return b.store.Upsert(p.GameID, p)
+}
+
+func (b *BadgerHoldStorage) GetGameProof(ctx context.Context, gameID string) (*model.GameProof, error) {
+	var p model.GameProof
+	err := b.store.Get(gameID, &p)
+	return &p, err
+}
+
+func (b *BadgerHoldStorage) SaveGameEvents(ctx context.Context, events []model.GameEvent) error {
+	return b.update(func(tx *badger.Txn) error {
+		for i := range events {
+			if err := b.store.TxInsert(tx, badgerhold.NextSequence(), &events[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (b *BadgerHoldStorage) ListGameEvents(ctx context.Context, gameID string) ([]model.GameEvent, error) {
+	var events []model.GameEvent
+	err := b.store.Find(&events, badgerhold.Where("GameID").Eq(gameID).Index("GameID").SortBy("Seq"))
+	return events, err
+}
+
+func (b *BadgerHoldStorage) EscrowBets(ctx context.Context, gameID string, bets map[string]uint64) error {
+	return b.update(func(tx *badger.Txn) error {
+		var e model.Escrow
+		err := b.store.TxGet(tx, gameID, &e)
+		if err == nil {
+			return nil
+		} else if !model.IsNotFound(err) {
+			return err
+		}
+
+		e = model.Escrow{GameID: gameID, CreatedAt: time.Now()}
+		legs := make(map[string]int64)
+		for _, id := range sortedKeys(bets) {
+			amount := bets[id]
+			var p model.Player
+			if err := b.store.TxGet(tx, id, &p); err != nil {
+				return err
+			}
+			if p.Balance < int64(amount) {
+				return fmt.Errorf("%s %w", p.Name, model.ErrInsufficientBalance)
+			}
+			p.Balance -= int64(amount)
+			if err := b.store.TxUpdate(tx, id, &p); err != nil {
+				return err
+			}
+			e.Holds = append(e.Holds, model.EscrowHold{PlayerID: id, Amount: amount})
+			legs[id] -= int64(amount)
+			legs[model.AccountEscrow] += int64(amount)
+		}
+		if err := b.post(tx, model.LedgerBet, gameID, legs); err != nil {
+			return err
+		}
+		return b.store.TxInsert(tx, gameID, &e)
+	})
+}
+
+// SettleGame pays the game, repays the auto repaid loans of the winners from their rewards and saves the records
+// of the game in one transaction
+func (b *BadgerHoldStorage) SettleGame(ctx context.Context, gameID string, rewards map[string]int64, records []model.Record, gr *model.GameRecord) ([]model.Repayment, error) {
+	var repayments []model.Repayment
+	err := b.update(func(tx *badger.Txn) error {
+		repayments = nil
+		var e model.Escrow
+		err := b.store.TxGet(tx, gameID, &e)
+		if err == nil && e.Settled {
+			return model.ErrAlreadySettled
+		} else if err != nil && !model.IsNotFound(err) {
+			return err
+		} else if err != nil {
+			// games dealt before escrow was added
+			e = model.Escrow{GameID: gameID, CreatedAt: time.Now()}
+		}
+
+		credits := make(map[string]int64)
+		held := int64(0)
+		for _, h := range e.Holds {
+			credits[h.PlayerID] += int64(h.Amount)
+			held += int64(h.Amount)
+		}
+		for id, reward := range rewards {
+			credits[id] += reward
+		}
+		for _, id := range sortedKeys(credits) {
+			if credits[id] == 0 {
+				continue
+			}
+			var p model.Player
+			if err := b.store.TxGet(tx, id, &p); err != nil {
+				return err
+			}
+			p.Balance += credits[id]
+			if err := b.store.TxUpdate(tx, id, &p); err != nil {
+				return err
+			}
+		}
+
+		legs := map[string]int64{model.AccountEscrow: -held}
+		for id, credit := range credits {
+			legs[id] = credit
+		}
+		if err := b.post(tx, model.LedgerPayout, gameID, legs); err != nil {
+			return err
+		}
+		for _, id := range sortedKeys(rewards) {
+			if rewards[id] <= 0 {
+				continue
+			}
+			rs, err := b.repayLoans(tx, id, "", rewards[id], true)
+			if err != nil {
+				return err
+			}
+			repayments = append(repayments, rs...)
+		}
+
+		for i := range records {
+			if err := b.store.TxInsert(tx, badgerhold.NextSequence(), &records[i]); err != nil {
+				return err
+			}
+			if err := b.addTotals(tx, &records[i]); err != nil {
+				return err
+			}
+		}
+		if gr != nil {
+			if err := b.store.TxUpsert(tx, gr.ID, gr); err != nil {
+				return err
+			}
+		}
+
+		e.Settled = true
+		e.SettledAt = time.Now()
+		return b.store.TxUpsert(tx, gameID, &e)
+	})
+	return repayments, err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// newTestStorage opens a store in a temporary directory with the players and their balances
+func newTestStorage(t *testing.T, balances map[string]int64) *BadgerHoldStorage {
+	t.Helper()
+	b := NewBadgerHoldStorage(t.TempDir())
+	t.Cleanup(func() {
+		_ = b.Close()
+	})
+	for _, id := range sortedKeys(balances) {
+		if err := b.SavePlayer(context.Background(), &model.Player{ID: id, TelegramID: id, Name: "Player #" + id, Balance: balances[id]}); err != nil {
+			t.Fatalf("SavePlayer() error = %v", err)
+		}
+	}
+	return b
+}
+
+// checkBalances checks the balances of the players and that they match the ledger, which must sum to zero
+func checkBalances(t *testing.T, b *BadgerHoldStorage, want map[string]int64) {
+	t.Helper()
+	ctx := context.Background()
+	ledger, err := b.LedgerBalances(ctx)
+	if err != nil {
+		t.Fatalf("LedgerBalances() error = %v", err)
+	}
+	sum := int64(0)
+	for _, amount := range ledger {
+		sum += amount
+	}
+	if sum != 0 {
+		t.Errorf("ledger sums to %d, want 0", sum)
+	}
+	for _, id := range sortedKeys(want) {
+		if id == model.AccountEscrow || id == model.AccountHouse {
+			if ledger[id] != want[id] {
+				t.Errorf("ledger balance of %s = %d, want %d", id, ledger[id], want[id])
+			}
+			continue
+		}
+		p, err := b.GetPlayerByID(ctx, id)
+		if err != nil {
+			t.Fatalf("GetPlayerByID() error = %v", err)
+		}
+		if p.Balance != want[id] || ledger[id] != want[id] {
+			t.Errorf("balance of %s = %d, ledger %d, want %d", id, p.Balance, ledger[id], want[id])
+		}
+	}
+}
+
+func TestBadgerHoldStorage_SettleGame(t *testing.T) {
+	ctx := context.Background()
+	b := newTestStorage(t, map[string]int64{"1": 1000, "2": 100, "3": 50})
+
+	bets := map[string]uint64{"2": 30, "3": 20}
+	if err := b.EscrowBets(ctx, "g1", bets); err != nil {
+		t.Fatalf("EscrowBets() error = %v", err)
+	}
+	// escrowing again, e.g. when a restored game is dealt again, takes nothing
+	if err := b.EscrowBets(ctx, "g1", bets); err != nil {
+		t.Fatalf("EscrowBets() again error = %v", err)
+	}
+	checkBalances(t, b, map[string]int64{"1": 1000, "2": 70, "3": 30, model.AccountEscrow: 50})
+
+	if err := b.EscrowBets(ctx, "g2", map[string]uint64{"2": 10, "3": 40}); !errors.Is(err, model.ErrInsufficientBalance) {
+		t.Errorf("EscrowBets() error = %v, want %v", err, model.ErrInsufficientBalance)
+	}
+	checkBalances(t, b, map[string]int64{"1": 1000, "2": 70, "3": 30, model.AccountEscrow: 50})
+
+	now := time.Now()
+	records := []model.Record{{GameID: "g1", PlayerID: "1", Reward: -10, CreatedAt: now}, {GameID: "g1", PlayerID: "2", Reward: 30, CreatedAt: now}}
+	gr := &model.GameRecord{ID: "g1", DealerID: "1", Status: model.GameFinished, EndedAt: now}
+	if _, err := b.SettleGame(ctx, "g1", map[string]int64{"1": -10, "2": 30}, records, gr); !errors.Is(err, model.ErrUnbalancedEntry) {
+		t.Errorf("SettleGame() error = %v, want %v", err, model.ErrUnbalancedEntry)
+	}
+	checkBalances(t, b, map[string]int64{"1": 1000, "2": 70, "3": 30, model.AccountEscrow: 50})
+
+	if games, _ := b.ListGameRecords(ctx, time.Time{}, time.Time{}); len(games) != 0 {
+		t.Errorf("ListGameRecords() = %+v, want none saved by a failed settlement", games)
+	}
+
+	rewards := map[string]int64{"1": -10, "2": 30, "3": -20}
+	records = append(records, model.Record{GameID: "g1", PlayerID: "3", Reward: -20, CreatedAt: now})
+	if _, err := b.SettleGame(ctx, "g1", rewards, records, gr); err != nil {
+		t.Fatalf("SettleGame() error = %v", err)
+	}
+	want := map[string]int64{"1": 990, "2": 130, "3": 30, model.AccountEscrow: 0}
+	checkBalances(t, b, want)
+	if got, _ := b.ListRecords(ctx, "2", time.Time{}, time.Time{}, model.Cursor{}, 10); len(got) != 1 || got[0].Reward != 30 {
+		t.Errorf("ListRecords() = %+v, want the record of the game", got)
+	}
+	if games, _ := b.ListGameRecords(ctx, time.Time{}, time.Time{}); len(games) != 1 || games[0].ID != "g1" {
+		t.Errorf("ListGameRecords() = %+v, want the game", games)
+	}
+
+	if _, err := b.SettleGame(ctx, "g1", rewards, nil, nil); !errors.Is(err, model.ErrAlreadySettled) {
+		t.Errorf("SettleGame() again error = %v, want %v", err, model.ErrAlreadySettled)
+	}
+	if err := b.EscrowBets(ctx, "g1", bets); err != nil {
+		t.Fatalf("EscrowBets() after settle error = %v", err)
+	}
+	checkBalances(t, b, want)
+
+	// cancelling refunds the bets
+	if err := b.EscrowBets(ctx, "g3", map[string]uint64{"2": 100}); err != nil {
+		t.Fatalf("EscrowBets() error = %v", err)
+	}
+	if _, err := b.SettleGame(ctx, "g3", nil, nil, nil); err != nil {
+		t.Fatalf("SettleGame() refund error = %v", err)
+	}
+	checkBalances(t, b, want)
+}
+
+func TestBadgerHoldStorage_ListRecords(t *testing.T) {
+	ctx := context.Background()
+	b := newTestStorage(t, nil)
+	start := time.Now().Add(-time.Hour)
+	for i := 1; i <= 7; i++ {
+		for _, id := range []string{"1", "2"} {
+			r := &model.Record{GameID: "g", PlayerID: id, Reward: int64(i), CreatedAt: start.Add(time.Duration(i) * time.Minute)}
+			if err := b.SaveRecord(ctx, r); err != nil {
+				t.Fatalf("SaveRecord() error = %v", err)
+			}
+		}
+	}
+
+	rewards := func(records []model.Record) []int64 {
+		res := make([]int64, len(records))
+		for i, r := range records {
+			res[i] = r.Reward
+		}
+		return res
+	}
+	page1, _ := b.ListRecords(ctx, "1", time.Time{}, time.Time{}, model.Cursor{}, 3)
+	page2, _ := b.ListRecords(ctx, "1", time.Time{}, time.Time{}, model.Cursor{Before: page1[len(page1)-1].ID}, 3)
+	tests := []struct {
+		name   string
+		from   time.Time
+		cursor model.Cursor
+		want   []int64
+	}{
+		{name: "newest", want: []int64{7, 6, 5}},
+		{name: "older", cursor: model.Cursor{Before: page1[2].ID}, want: []int64{4, 3, 2}},
+		{name: "oldest", cursor: model.Cursor{Before: page2[2].ID}, want: []int64{1}},
+		{name: "newer", cursor: model.Cursor{After: page2[0].ID}, want: []int64{7, 6, 5}},
+		{name: "newer at the end", cursor: model.Cursor{After: page2[2].ID}, want: []int64{5, 4, 3}},
+		{name: "from", from: start.Add(5 * time.Minute), want: []int64{7, 6, 5}},
+		{name: "from, older", from: start.Add(5 * time.Minute), cursor: model.Cursor{Before: page1[1].ID}, want: []int64{5}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, err := b.ListRecords(ctx, "1", tt.from, time.Time{}, tt.cursor, 3)
+			if err != nil {
+				t.Fatalf("ListRecords() error = %v", err)
+			}
+			got := rewards(records)
+			if len(got) != len(tt.want) {
+				t.Fatalf("ListRecords() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] || records[i].PlayerID != "1" {
+					t.Fatalf("ListRecords() = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBadgerHoldStorage_AggregateRecords(t *testing.T) {
+	ctx := context.Background()
+	b := newTestStorage(t, nil)
+	start := time.Now().Add(-time.Hour)
+	// more records than fit in one byte keys, whose index is not in insertion order
+	for i := 0; i < 150; i++ {
+		reward := int64(10)
+		if i >= 145 {
+			reward = -10
+		}
+		r := &model.Record{GameID: "g", PlayerID: "1", Reward: reward, IsDealer: i%2 == 0, CreatedAt: start.Add(time.Duration(i) * time.Second)}
+		if err := b.SaveRecord(ctx, r); err != nil {
+			t.Fatalf("SaveRecord() error = %v", err)
+		}
+	}
+
+	s, err := b.AggregateRecords(ctx, "1", time.Time{}, time.Time{}, model.StatsAll)
+	if err != nil {
+		t.Fatalf("AggregateRecords() error = %v", err)
+	}
+	if s.Games != 150 || s.Net != 1400 || s.Streak != -5 || s.LongestWins != 145 || s.LongestLosses != 5 {
+		t.Errorf("AggregateRecords() = %d games, net %d, streaks %d, %d, %d, want 150, 1400, -5, 145, 5", s.Games, s.Net, s.Streak, s.LongestWins, s.LongestLosses)
+	}
+	s, _ = b.AggregateRecords(ctx, "1", start.Add(100*time.Second), time.Time{}, model.StatsDealer)
+	if s.Games != 25 {
+		t.Errorf("AggregateRecords() as dealer = %d games, want 25", s.Games)
+	}
+}
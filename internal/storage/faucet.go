@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func (b *BadgerHoldStorage) getFaucet(tx *badger.Txn, playerID string) (*model.Faucet, error) {
+	f := &model.Faucet{}
+	err := b.store.TxGet(tx, playerID, f)
+	if model.IsNotFound(err) {
+		return &model.Faucet{PlayerID: playerID}, nil
+	}
+	return f, err
+}
+
+// GetFaucet returns the faucet state of the player, an empty one if they never claimed
+func (b *BadgerHoldStorage) GetFaucet(ctx context.Context, playerID string) (*model.Faucet, error) {
+	var f *model.Faucet
+	err := b.store.Badger().View(func(tx *badger.Txn) error {
+		var err error
+		f, err = b.getFaucet(tx, playerID)
+		return err
+	})
+	return f, err
+}
+
+// UpdateFaucet runs fn on the faucet state and the balance of the player in one transaction, the state is saved
+// and the positive amount returned by fn is granted from the house for the reason. Nothing is saved if fn fails.
+func (b *BadgerHoldStorage) UpdateFaucet(ctx context.Context, playerID string, reason model.LedgerReason, fn func(f *model.Faucet, balance int64) (int64, error)) (*model.Player, error) {
+	var p model.Player
+	err := b.update(func(tx *badger.Txn) error {
+		p = model.Player{}
+		if err := b.store.TxGet(tx, playerID, &p); err != nil {
+			return err
+		}
+		f, err := b.getFaucet(tx, playerID)
+		if err != nil {
+			return err
+		}
+		amount, err := fn(f, p.Balance)
+		if err != nil {
+			return err
+		}
+		if err := b.store.TxUpsert(tx, playerID, f); err != nil {
+			return err
+		}
+		if amount <= 0 {
+			return nil
+		}
+		p.Balance += amount
+		if err := b.store.TxUpdate(tx, playerID, &p); err != nil {
+			return err
+		}
+		return b.post(tx, reason, "", map[string]int64{playerID: amount, model.AccountHouse: -amount})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// markGave keeps when the player last gave money to another player
+func (b *BadgerHoldStorage) markGave(tx *badger.Txn, playerID string, at time.Time) error {
+	f, err := b.getFaucet(tx, playerID)
+	if err != nil {
+		return err
+	}
+	f.LastGaveAt = at
+	return b.store.TxUpsert(tx, playerID, f)
+}
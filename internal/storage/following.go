@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/timshannon/badgerhold/v4"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// Follow adds the following, it returns model.ErrExists if the follower already follows the followee
+func (b *BadgerHoldStorage) Follow(ctx context.Context, followerID, followeeID string) error {
+	return b.update(func(tx *badger.Txn) error {
+		cnt, err := b.store.TxCount(tx, &model.Following{}, followingQuery(followerID, followeeID))
+		if err != nil {
+			return err
+		}
+		if cnt > 0 {
+			return model.ErrExists
+		}
+		return b.store.TxInsert(tx, badgerhold.NextSequence(), &model.Following{FollowerID: followerID, FolloweeID: followeeID})
+	})
+}
+
+// Unfollow removes the following, it returns model.ErrNotFound if there is none
+func (b *BadgerHoldStorage) Unfollow(ctx context.Context, followerID, followeeID string) error {
+	return b.update(func(tx *badger.Txn) error {
+		cnt, err := b.store.TxCount(tx, &model.Following{}, followingQuery(followerID, followeeID))
+		if err != nil {
+			return err
+		}
+		if cnt == 0 {
+			return model.ErrNotFound
+		}
+		return b.store.TxDeleteMatching(tx, &model.Following{}, followingQuery(followerID, followeeID))
+	})
+}
+
+func (b *BadgerHoldStorage) ListFollowees(ctx context.Context, followerID string) ([]model.Following, error) {
+	var fs []model.Following
+	err := b.store.Find(&fs, badgerhold.Where("FollowerID").Eq(followerID).Index("FollowerID"))
+	return fs, err
+}
+
+func (b *BadgerHoldStorage) ListFollowers(ctx context.Context, followeeID string) ([]model.Following, error) {
+	var fs []model.Following
+	err := b.store.Find(&fs, badgerhold.Where("FolloweeID").Eq(followeeID).Index("FolloweeID"))
+	return fs, err
+}
+
+func followingQuery(followerID, followeeID string) *badgerhold.Query {
+	return badgerhold.Where("FollowerID").Eq(followerID).Index("FollowerID").And("FolloweeID").Eq(followeeID)
+}
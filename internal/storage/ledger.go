@@ -0,0 +1,133 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/rs/xid"
+	"github.com/timshannon/badgerhold/v4"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// post writes the legs of a balance movement to the ledger, the legs must sum to zero
+func (b *BadgerHoldStorage) post(tx *badger.Txn, reason model.LedgerReason, reference string, legs map[string]int64) error {
+	sum := int64(0)
+	for _, amount := range legs {
+		sum += amount
+	}
+	if sum != 0 {
+		return model.ErrUnbalancedEntry
+	}
+
+	txID := xid.New().String()
+	now := time.Now()
+	for _, account := range sortedKeys(legs) {
+		if legs[account] == 0 {
+			continue
+		}
+		e := &model.LedgerEntry{
+			TxID:      txID,
+			Account:   account,
+			Amount:    legs[account],
+			Reason:    reason,
+			Reference: reference,
+			CreatedAt: now,
+		}
+		if err := b.store.TxInsert(tx, badgerhold.NextSequence(), e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transfer moves amount from a player to another, it fails with model.ErrInsufficientBalance if the sender cannot afford it.
+// Giving money away with a transfer or a loan is kept in the sender's faucet state.
+func (b *BadgerHoldStorage) transfer(tx *badger.Txn, fromID, toID string, amount int64, reason model.LedgerReason, reference string) (from, to *model.Player, err error) {
+	from, to = &model.Player{}, &model.Player{}
+	if err := b.store.TxGet(tx, fromID, from); err != nil {
+		return nil, nil, err
+	}
+	if err := b.store.TxGet(tx, toID, to); err != nil {
+		return nil, nil, err
+	}
+	if from.Balance < amount {
+		return nil, nil, model.ErrInsufficientBalance
+	}
+	from.Balance -= amount
+	to.Balance += amount
+	if err := b.store.TxUpdate(tx, fromID, from); err != nil {
+		return nil, nil, err
+	}
+	if err := b.store.TxUpdate(tx, toID, to); err != nil {
+		return nil, nil, err
+	}
+	if reason == model.LedgerTransfer || reason == model.LedgerLoan {
+		if err := b.markGave(tx, fromID, time.Now()); err != nil {
+			return nil, nil, err
+		}
+	}
+	return from, to, b.post(tx, reason, reference, map[string]int64{fromID: -amount, toID: amount})
+}
+
+// Transfer moves amount between two players in one transaction
+func (b *BadgerHoldStorage) Transfer(ctx context.Context, fromID, toID string, amount int64) (from, to *model.Player, err error) {
+	err = b.update(func(tx *badger.Txn) error {
+		var err error
+		from, to, err = b.transfer(tx, fromID, toID, amount, model.LedgerTransfer, fromID)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return from, to, nil
+}
+
+// OpenLedger posts the balances of the players who have no ledger entries yet as deposits,
+// so that balances from before the ledger was added can be reconciled
+func (b *BadgerHoldStorage) OpenLedger(ctx context.Context) error {
+	return b.update(func(tx *badger.Txn) error {
+		var players []model.Player
+		if err := b.store.TxFind(tx, &players, nil); err != nil {
+			return err
+		}
+		for _, p := range players {
+			if p.Balance == 0 {
+				continue
+			}
+			cnt, err := b.store.TxCount(tx, &model.LedgerEntry{}, badgerhold.Where("Account").Eq(p.ID).Index("Account"))
+			if err != nil {
+				return err
+			}
+			if cnt > 0 {
+				continue
+			}
+			if err := b.post(tx, model.LedgerDeposit, "opening", map[string]int64{p.ID: p.Balance, model.AccountHouse: -p.Balance}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListLedgerEntries returns the latest entries of the account, newest first
+func (b *BadgerHoldStorage) ListLedgerEntries(ctx context.Context, account string, limit int) ([]model.LedgerEntry, error) {
+	var entries []model.LedgerEntry
+	q := badgerhold.Where("Account").Eq(account).Index("Account").SortBy("CreatedAt").Reverse()
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	err := b.store.Find(&entries, q)
+	return entries, err
+}
+
+// LedgerBalances sums the entries of every account
+func (b *BadgerHoldStorage) LedgerBalances(ctx context.Context) (map[string]int64, error) {
+	balances := make(map[string]int64)
+	err := b.store.ForEach(nil, func(e *model.LedgerEntry) error {
+		balances[e.Account] += e.Amount
+		return nil
+	})
+	return balances, err
+}
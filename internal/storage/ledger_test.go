@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/timshannon/badgerhold/v4"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestBadgerHoldStorage_Transfer(t *testing.T) {
+	ctx := context.Background()
+	b := newTestStorage(t, map[string]int64{"1": 100, "2": 50})
+	checkBalances(t, b, map[string]int64{"1": 100, "2": 50, model.AccountHouse: -150})
+
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		amount  int64
+		want    map[string]int64
+		wantErr error
+	}{
+		{name: "transfer", from: "1", to: "2", amount: 30, want: map[string]int64{"1": 70, "2": 80}},
+		{name: "whole balance", from: "2", to: "1", amount: 80, want: map[string]int64{"1": 150, "2": 0}},
+		{name: "more than balance", from: "2", to: "1", amount: 1, want: map[string]int64{"1": 150, "2": 0}, wantErr: model.ErrInsufficientBalance},
+		{name: "unknown recipient", from: "1", to: "3", amount: 10, want: map[string]int64{"1": 150, "2": 0}, wantErr: badgerhold.ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := b.Transfer(ctx, tt.from, tt.to, tt.amount)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Transfer() error = %v, want %v", err, tt.wantErr)
+			}
+			checkBalances(t, b, tt.want)
+		})
+	}
+
+	f, _ := b.GetFaucet(ctx, "2")
+	if f.LastGaveAt.IsZero() {
+		t.Errorf("LastGaveAt of the sender is not set")
+	}
+
+	if _, err := b.AddPlayerBalance(ctx, "2", 20, "9"); err != nil {
+		t.Fatalf("AddPlayerBalance() error = %v", err)
+	}
+	if _, err := b.AddPlayerBalance(ctx, "1", -50, "9"); err != nil {
+		t.Fatalf("AddPlayerBalance() error = %v", err)
+	}
+	checkBalances(t, b, map[string]int64{"1": 100, "2": 20, model.AccountHouse: -120})
+
+	entries, err := b.ListLedgerEntries(ctx, "1", 2)
+	if err != nil {
+		t.Fatalf("ListLedgerEntries() error = %v", err)
+	}
+	if len(entries) != 2 || entries[0].Reason != model.LedgerWithdrawal || entries[1].Reason != model.LedgerTransfer {
+		t.Errorf("ListLedgerEntries() = %+v, want the withdrawal then the transfer", entries)
+	}
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/timshannon/badgerhold/v4"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// CreateLoan pays the amount of the loan from the lender to the borrower and saves the loan in one transaction
+func (b *BadgerHoldStorage) CreateLoan(ctx context.Context, l *model.Loan) error {
+	return b.update(func(tx *badger.Txn) error {
+		if _, _, err := b.transfer(tx, l.LenderID, l.BorrowerID, l.Amount, model.LedgerLoan, l.ID); err != nil {
+			return err
+		}
+		return b.store.TxInsert(tx, l.ID, l)
+	})
+}
+
+// RepayLoans repays the open loans of the borrower oldest first, only those from the lender if it is not empty
+// and only those repaid from winnings if auto is set. At most amount is repaid, everything owed when amount is 0.
+// Auto repayments are also capped at the borrower's balance.
+func (b *BadgerHoldStorage) RepayLoans(ctx context.Context, borrowerID, lenderID string, amount int64, auto bool) ([]model.Repayment, error) {
+	var repayments []model.Repayment
+	err := b.update(func(tx *badger.Txn) error {
+		var err error
+		repayments, err = b.repayLoans(tx, borrowerID, lenderID, amount, auto)
+		return err
+	})
+	return repayments, err
+}
+
+func (b *BadgerHoldStorage) repayLoans(tx *badger.Txn, borrowerID, lenderID string, amount int64, auto bool) ([]model.Repayment, error) {
+	q := badgerhold.Where("BorrowerID").Eq(borrowerID).Index("BorrowerID").And("Closed").Eq(false)
+	if len(lenderID) > 0 {
+		q = q.And("LenderID").Eq(lenderID)
+	}
+	if auto {
+		q = q.And("AutoRepay").Eq(true)
+	}
+	var loans []model.Loan
+	if err := b.store.TxFind(tx, &loans, q.SortBy("CreatedAt")); err != nil {
+		return nil, err
+	}
+	if auto {
+		// winnings repay no more than the borrower has, whose balance may have been negative before the game
+		var p model.Player
+		if err := b.store.TxGet(tx, borrowerID, &p); err != nil {
+			return nil, err
+		}
+		if amount == 0 || p.Balance < amount {
+			amount = p.Balance
+		}
+		if amount <= 0 {
+			return nil, nil
+		}
+	}
+
+	var repayments []model.Repayment
+	now := time.Now()
+	left := amount
+	for _, l := range loans {
+		pay := l.Outstanding()
+		if amount > 0 && pay > left {
+			pay = left
+		}
+		if pay <= 0 {
+			break
+		}
+		if _, _, err := b.transfer(tx, l.BorrowerID, l.LenderID, pay, model.LedgerRepayment, l.ID); err != nil {
+			return nil, err
+		}
+		l.Repaid += pay
+		if l.Outstanding() == 0 {
+			l.Closed, l.ClosedAt = true, now
+		}
+		if err := b.store.TxUpdate(tx, l.ID, &l); err != nil {
+			return nil, err
+		}
+		repayments = append(repayments, model.Repayment{Loan: l, Amount: pay})
+		left -= pay
+	}
+	return repayments, nil
+}
+
+// ListLoans returns the open loans lent or borrowed by the player, oldest first
+func (b *BadgerHoldStorage) ListLoans(ctx context.Context, playerID string) ([]model.Loan, error) {
+	var loans []model.Loan
+	q := badgerhold.Where("Closed").Eq(false).Index("Closed").
+		And("LenderID").Eq(playerID).
+		Or(badgerhold.Where("Closed").Eq(false).Index("Closed").And("BorrowerID").Eq(playerID))
+	err := b.store.Find(&loans, q.SortBy("CreatedAt"))
+	return loans, err
+}
+
+// ListDueLoans returns the open loans due before the time, oldest due first
+func (b *BadgerHoldStorage) ListDueLoans(ctx context.Context, before time.Time) ([]model.Loan, error) {
+	var loans []model.Loan
+	q := badgerhold.Where("Closed").Eq(false).Index("Closed").
+		And("DueAt").Gt(time.Time{}).And("DueAt").Lt(before)
+	err := b.store.Find(&loans, q.SortBy("DueAt"))
+	return loans, err
+}
+
+func (b *BadgerHoldStorage) MarkLoanReminded(ctx context.Context, id string, at time.Time) error {
+	return b.store.UpdateMatching(&model.Loan{}, badgerhold.Where(badgerhold.Key).Eq(id), func(record interface{}) error {
+		record.(*model.Loan).RemindedAt = at
+		return nil
+	})
+}
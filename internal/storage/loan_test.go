@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestBadgerHoldStorage_RepayLoans(t *testing.T) {
+	ctx := context.Background()
+	b := newTestStorage(t, map[string]int64{"1": 1000, "2": 500, "3": 100})
+	now := time.Now()
+	for i, l := range []model.Loan{
+		{ID: "l1", LenderID: "1", BorrowerID: "2", Amount: 300, AutoRepay: true},
+		{ID: "l2", LenderID: "1", BorrowerID: "2", Amount: 100},
+		{ID: "l3", LenderID: "3", BorrowerID: "2", Amount: 50, AutoRepay: true},
+	} {
+		l.CreatedAt = now.Add(time.Duration(i) * time.Minute)
+		if err := b.CreateLoan(ctx, &l); err != nil {
+			t.Fatalf("CreateLoan() error = %v", err)
+		}
+	}
+	checkBalances(t, b, map[string]int64{"1": 600, "2": 950, "3": 50})
+	if f, _ := b.GetFaucet(ctx, "1"); f.LastGaveAt.IsZero() {
+		t.Errorf("LastGaveAt of the lender is not set")
+	}
+
+	tests := []struct {
+		name    string
+		lender  string
+		amount  int64
+		auto    bool
+		want    []int64 // repaid amounts
+		balance map[string]int64
+		wantErr error
+	}{
+		{name: "winnings, oldest first", amount: 320, auto: true, want: []int64{300, 20}, balance: map[string]int64{"1": 900, "2": 630, "3": 70}},
+		{name: "part to a lender", lender: "3", amount: 10, want: []int64{10}, balance: map[string]int64{"2": 620, "3": 80}},
+		{name: "all to a lender", lender: "1", want: []int64{100}, balance: map[string]int64{"1": 1000, "2": 520}},
+		{name: "nothing owed", lender: "1", balance: map[string]int64{"1": 1000, "2": 520}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repayments, err := b.RepayLoans(ctx, "2", tt.lender, tt.amount, tt.auto)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("RepayLoans() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(repayments) != len(tt.want) {
+				t.Fatalf("RepayLoans() = %+v, want %v", repayments, tt.want)
+			}
+			for i, r := range repayments {
+				if r.Amount != tt.want[i] || r.Loan.Closed != (r.Loan.Outstanding() == 0) {
+					t.Errorf("RepayLoans() = %+v, want %v", repayments, tt.want)
+				}
+			}
+			checkBalances(t, b, tt.balance)
+		})
+	}
+
+	// repaying is not giving money away
+	if f, _ := b.GetFaucet(ctx, "2"); !f.LastGaveAt.IsZero() {
+		t.Errorf("LastGaveAt of the borrower = %v, want zero", f.LastGaveAt)
+	}
+
+	// the borrower spent the money elsewhere
+	if _, _, err := b.Transfer(ctx, "2", "1", 505); err != nil {
+		t.Fatalf("Transfer() error = %v", err)
+	}
+	if _, err := b.RepayLoans(ctx, "2", "3", 0, false); !errors.Is(err, model.ErrInsufficientBalance) {
+		t.Errorf("RepayLoans() error = %v, want %v", err, model.ErrInsufficientBalance)
+	}
+	checkBalances(t, b, map[string]int64{"1": 1505, "2": 15, "3": 80})
+
+	loans, err := b.ListLoans(ctx, "2")
+	if err != nil {
+		t.Fatalf("ListLoans() error = %v", err)
+	}
+	if len(loans) != 1 || loans[0].ID != "l3" || loans[0].Outstanding() != 20 {
+		t.Errorf("ListLoans() = %+v, want l3 owing 20", loans)
+	}
+}
+
+func TestBadgerHoldStorage_SettleGameRepays(t *testing.T) {
+	ctx := context.Background()
+	b := newTestStorage(t, map[string]int64{"1": 100, "2": 100})
+	if err := b.CreateLoan(ctx, &model.Loan{ID: "l1", LenderID: "1", BorrowerID: "2", Amount: 50, AutoRepay: true, CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("CreateLoan() error = %v", err)
+	}
+	if err := b.EscrowBets(ctx, "g1", map[string]uint64{"2": 10}); err != nil {
+		t.Fatalf("EscrowBets() error = %v", err)
+	}
+
+	rewards := map[string]int64{"1": -20, "2": 20}
+	repayments, err := b.SettleGame(ctx, "g1", rewards, nil, nil)
+	if err != nil {
+		t.Fatalf("SettleGame() error = %v", err)
+	}
+	if len(repayments) != 1 || repayments[0].Amount != 20 || repayments[0].Loan.Repaid != 20 {
+		t.Errorf("SettleGame() = %+v, want 20 repaid", repayments)
+	}
+	want := map[string]int64{"1": 50, "2": 150, model.AccountEscrow: 0}
+	checkBalances(t, b, want)
+
+	// a settled game repays nothing again
+	if repayments, err := b.SettleGame(ctx, "g1", rewards, nil, nil); !errors.Is(err, model.ErrAlreadySettled) || len(repayments) != 0 {
+		t.Errorf("SettleGame() again = %+v, %v, want %v", repayments, err, model.ErrAlreadySettled)
+	}
+	checkBalances(t, b, want)
+
+	// winnings repay no more than the balance of a borrower who was in debt before the game
+	if _, err := b.AddPlayerBalance(ctx, "2", -170, "9"); err != nil {
+		t.Fatalf("AddPlayerBalance() error = %v", err)
+	}
+	if err := b.EscrowBets(ctx, "g2", nil); err != nil {
+		t.Fatalf("EscrowBets() error = %v", err)
+	}
+	repayments, err = b.SettleGame(ctx, "g2", map[string]int64{"1": -25, "2": 25}, nil, nil)
+	if err != nil {
+		t.Fatalf("SettleGame() error = %v", err)
+	}
+	if len(repayments) != 1 || repayments[0].Amount != 5 || repayments[0].Loan.Repaid != 25 {
+		t.Errorf("SettleGame() = %+v, want 5 repaid", repayments)
+	}
+	checkBalances(t, b, map[string]int64{"1": 30, "2": 0})
+
+	// nothing is repaid while the balance stays negative
+	if _, err := b.AddPlayerBalance(ctx, "2", -50, "9"); err != nil {
+		t.Fatalf("AddPlayerBalance() error = %v", err)
+	}
+	if err := b.EscrowBets(ctx, "g3", nil); err != nil {
+		t.Fatalf("EscrowBets() error = %v", err)
+	}
+	if repayments, err := b.SettleGame(ctx, "g3", map[string]int64{"1": -10, "2": 10}, nil, nil); err != nil || len(repayments) != 0 {
+		t.Errorf("SettleGame() = %+v, %v, want nothing repaid", repayments, err)
+	}
+	checkBalances(t, b, map[string]int64{"1": 20, "2": -40})
+}
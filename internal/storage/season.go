@@ -0,0 +1,94 @@
+package storage
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/timshannon/badgerhold/v4"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+// currentSeason returns the running season, the first season is not stored until it is closed
+func (b *BadgerHoldStorage) currentSeason(tx *badger.Txn) (*model.Season, error) {
+	var seasons []model.Season
+	if err := b.store.TxFind(tx, &seasons, badgerhold.Where("Closed").Eq(false).Index("Closed")); err != nil {
+		return nil, err
+	}
+	if len(seasons) == 0 {
+		return &model.Season{Number: 1}, nil
+	}
+	return &seasons[0], nil
+}
+
+// closeSeason snapshots the balances and the stats of the players in the running season,
+// then starts the next season with the new balance
+func (b *BadgerHoldStorage) closeSeason(tx *badger.Txn, players []model.Player, newBalance int64, operatorID string) (*model.Season, error) {
+	s, err := b.currentSeason(tx)
+	if err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]*model.PlayerTotals)
+	err = b.store.TxForEach(tx, badgerhold.Where("CreatedAt").Ge(s.StartedAt), func(r *model.Record) error {
+		if totals[r.PlayerID] == nil {
+			totals[r.PlayerID] = &model.PlayerTotals{PlayerID: r.PlayerID}
+		}
+		totals[r.PlayerID].Add(r)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.Standings = s.Standings[:0]
+	for _, p := range players {
+		if p.IsBot() {
+			continue
+		}
+		st := model.SeasonStanding{PlayerID: p.ID, Name: p.Name, Balance: p.Balance}
+		if t := totals[p.ID]; t != nil {
+			st.Games, st.Wins, st.Net = t.Games, t.Wins, t.Net
+		}
+		s.Standings = append(s.Standings, st)
+	}
+	sort.SliceStable(s.Standings, func(i, j int) bool {
+		a, c := s.Standings[i], s.Standings[j]
+		if a.Balance != c.Balance {
+			return a.Balance > c.Balance
+		}
+		return a.Net > c.Net
+	})
+	for i := range s.Standings {
+		s.Standings[i].Rank = i + 1
+	}
+
+	now := time.Now()
+	s.EndedAt, s.Closed, s.ClosedBy = now, true, operatorID
+	if err := b.store.TxUpsert(tx, s.Number, s); err != nil {
+		return nil, err
+	}
+	next := &model.Season{Number: s.Number + 1, Balance: newBalance, StartedAt: now}
+	if err := b.store.TxInsert(tx, next.Number, next); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (b *BadgerHoldStorage) CurrentSeason(ctx context.Context) (*model.Season, error) {
+	var s *model.Season
+	err := b.store.Badger().View(func(tx *badger.Txn) error {
+		var err error
+		s, err = b.currentSeason(tx)
+		return err
+	})
+	return s, err
+}
+
+func (b *BadgerHoldStorage) GetSeason(ctx context.Context, number int) (*model.Season, error) {
+	var s model.Season
+	err := b.store.Get(number, &s)
+	return &s, err
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/timshannon/badgerhold/v4"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func TestBadgerHoldStorage_ResetBalance(t *testing.T) {
+	ctx := context.Background()
+	b := newTestStorage(t, map[string]int64{"1": 800, "2": 1500, "BOT_3": 300})
+	_ = b.SaveRecord(ctx, &model.Record{GameID: "g1", PlayerID: "1", Reward: 30, CreatedAt: time.Now()})
+
+	if cur, err := b.CurrentSeason(ctx); err != nil || cur.Number != 1 || cur.Closed {
+		t.Fatalf("CurrentSeason() = %+v, %v, want the first season running", cur, err)
+	}
+
+	// the bets of a dealt game must not be carried into the next season
+	if err := b.EscrowBets(ctx, "g2", map[string]uint64{"1": 100}); err != nil {
+		t.Fatalf("EscrowBets() error = %v", err)
+	}
+	if _, err := b.ResetBalance(ctx, 2000, "9"); !errors.Is(err, model.ErrUnsettledGames) {
+		t.Fatalf("ResetBalance() error = %v, want %v", err, model.ErrUnsettledGames)
+	}
+	checkBalances(t, b, map[string]int64{"1": 700, "2": 1500, "BOT_3": 300, model.AccountEscrow: 100})
+	if _, err := b.SettleGame(ctx, "g2", nil, nil, nil); err != nil {
+		t.Fatalf("SettleGame() error = %v", err)
+	}
+
+	s, err := b.ResetBalance(ctx, 2000, "9")
+	if err != nil {
+		t.Fatalf("ResetBalance() error = %v", err)
+	}
+	if s.Number != 1 || !s.Closed || s.ClosedBy != "9" || len(s.Standings) != 2 {
+		t.Fatalf("ResetBalance() = %+v, want season 1 closed with 2 standings", s)
+	}
+	if st := s.Standings[0]; st.Rank != 1 || st.PlayerID != "2" || st.Balance != 1500 {
+		t.Errorf("first standing = %+v, want player 2 with 1500", st)
+	}
+	if st := s.Standings[1]; st.Rank != 2 || st.PlayerID != "1" || st.Balance != 800 || st.Games != 1 || st.Net != 30 {
+		t.Errorf("second standing = %+v, want player 1 with 800 and 1 game", st)
+	}
+	checkBalances(t, b, map[string]int64{"1": 2000, "2": 2000, "BOT_3": 2000, model.AccountEscrow: 0, model.AccountHouse: -6000})
+
+	cur, err := b.CurrentSeason(ctx)
+	if err != nil || cur.Number != 2 || cur.Balance != 2000 || cur.Closed {
+		t.Errorf("CurrentSeason() = %+v, %v, want season 2 running with 2000", cur, err)
+	}
+	if got, err := b.GetSeason(ctx, 1); err != nil || !got.Closed || len(got.Standings) != 2 {
+		t.Errorf("GetSeason(1) = %+v, %v, want the closed season", got, err)
+	}
+	if _, err := b.GetSeason(ctx, 3); !errors.Is(err, badgerhold.ErrNotFound) {
+		t.Errorf("GetSeason(3) error = %v, want %v", err, badgerhold.ErrNotFound)
+	}
+
+	// the next season only counts its own games
+	_ = b.SaveRecord(ctx, &model.Record{GameID: "g3", PlayerID: "2", Reward: -10, CreatedAt: time.Now()})
+	s, err = b.ResetBalance(ctx, 500, "9")
+	if err != nil || s.Number != 2 {
+		t.Fatalf("ResetBalance() = %+v, %v, want season 2", s, err)
+	}
+	for _, st := range s.Standings {
+		if want := map[string]int{"1": 0, "2": 1}[st.PlayerID]; st.Games != want || st.Balance != 2000 {
+			t.Errorf("standing of %s = %+v, want %d games and 2000", st.PlayerID, st, want)
+		}
+	}
+	checkBalances(t, b, map[string]int64{"1": 500, "2": 500, "BOT_3": 500, model.AccountHouse: -1500})
+}
@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/dgraph-io/badger/v4"
+	"github.com/timshannon/badgerhold/v4"
+
+	"github.com/psucodervn/verixilac/internal/model"
+)
+
+func (b *BadgerHoldStorage) addTotals(tx *badger.Txn, r *model.Record) error {
+	for _, day := range []string{model.TotalsDay(r.CreatedAt), ""} {
+		id := model.TotalsID(day, r.PlayerID)
+		var t model.PlayerTotals
+		if err := b.store.TxGet(tx, id, &t); err != nil && !model.IsNotFound(err) {
+			return err
+		}
+		t.ID, t.Day, t.PlayerID = id, day, r.PlayerID
+		t.Add(r)
+		if err := b.store.TxUpsert(tx, id, &t); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BuildTotals adds the records saved before the totals were kept, it does nothing if there are totals already
+func (b *BadgerHoldStorage) BuildTotals(ctx context.Context) error {
+	return b.update(func(tx *badger.Txn) error {
+		cnt, err := b.store.TxCount(tx, &model.PlayerTotals{}, nil)
+		if err != nil || cnt > 0 {
+			return err
+		}
+		var records []model.Record
+		if err := b.store.TxFind(tx, &records, nil); err != nil {
+			return err
+		}
+		for i := range records {
+			if err := b.addTotals(tx, &records[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Leaderboard returns the totals of every player since the day of from, or of all time when from is zero
+func (b *BadgerHoldStorage) Leaderboard(ctx context.Context, from time.Time) ([]model.PlayerTotals, error) {
+	var days []model.PlayerTotals
+	q := badgerhold.Where("Day").Eq("").Index("Day")
+	if !from.IsZero() {
+		q = badgerhold.Where("Day").Ge(model.TotalsDay(from)).Index("Day")
+	}
+	if err := b.store.Find(&days, q); err != nil {
+		return nil, err
+	}
+
+	totals := make(map[string]*model.PlayerTotals)
+	for i := range days {
+		if t, ok := totals[days[i].PlayerID]; ok {
+			t.Merge(&days[i])
+		} else {
+			totals[days[i].PlayerID] = &days[i]
+		}
+	}
+	res := make([]model.PlayerTotals, 0, len(totals))
+	for _, id := range sortedKeys(totals) {
+		res = append(res, *totals[id])
+	}
+	return res, nil
+}
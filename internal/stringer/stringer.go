@@ -2,6 +2,7 @@ package stringer
 
 import (
 	"strings"
+	"time"
 
 	"golang.org/x/text/language"
 	"golang.org/x/text/message"
@@ -40,3 +41,15 @@ func FormatCurrency[T Number](balance T) string {
 	b := balance
 	return printer.Sprintf("%d☘️", b)
 }
+
+// FormatDateRange describes [from, to), zero times mean no limit
+func FormatDateRange(from, to time.Time) string {
+	s := ""
+	if !from.IsZero() {
+		s += " từ " + from.Format("02/01/2006")
+	}
+	if !to.IsZero() {
+		s += " đến " + to.Add(-time.Second).Format("02/01/2006")
+	}
+	return s
+}
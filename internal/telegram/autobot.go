@@ -42,6 +42,9 @@ func fakeBet(ctx context.Context, h *Handler, g *game.Game, count int) {
 		if p, _ := h.game.PlayerRegister(ctx, botP1.ID, botP1.Name, botP1.UserRole); p == nil {
 			log.Error().Msg("cannot register bot")
 		}
+		if _, err := h.game.JoinRoom(ctx, botP1, g.RoomID()); err != nil && err != game.ErrYouAlreadyInRoom {
+			log.Err(err).Msg("fakeBet")
+		}
 		if err := h.game.PlayerBet(ctx, g.ID(), botP1, uint64(20*i)); err != nil {
 			log.Err(err).Msg("fakeBet")
 		}
@@ -2,6 +2,7 @@ package telegram
 
 import (
 	"fmt"
+	"time"
 
 	"gopkg.in/telebot.v3"
 
@@ -62,13 +63,34 @@ func MakeDealerPlayingButtons(g *game.Game, pg *game.PlayerInGame) []InlineButto
 func MakeDealerRevealButtons(g *game.Game) []InlineButton {
 	bs := make([]InlineButton, 0, len(g.PlayersInGame()))
 	for r, pg := range g.PlayersInGame() {
-		if pg.IsDone() {
+		if !g.CanReveal(pg) {
 			continue
 		}
 
 		s := fmt.Sprintf("Lật bài %s (%d lá)", pg.Name, len(pg.Cards()))
 		bs = append(bs, InlineButton{Text: s, Data: "/compare " + g.ID() + " " + pg.ID, Row: r})
 	}
+	remaining := len(bs)
+	if remaining < 2 {
+		return bs
+	}
+
+	// reveal everyone, or only the hands with many cards which are likely busted
+	row := len(g.PlayersInGame())
+	bs = append(bs, InlineButton{Text: "Lật hết", Data: "/revealall " + g.ID(), Row: row})
+	for n := 3; n <= g.Rule().HighFiveCards; n++ {
+		cnt := 0
+		for _, pg := range g.PlayersInGame() {
+			if g.CanReveal(pg) && len(pg.Cards()) >= n {
+				cnt++
+			}
+		}
+		if cnt == 0 || cnt == remaining {
+			continue
+		}
+		s := fmt.Sprintf("Lật hết ≥%d lá", n)
+		bs = append(bs, InlineButton{Text: s, Data: fmt.Sprintf("/revealall %s %d", g.ID(), n), Row: row})
+	}
 	return bs
 }
 
@@ -93,7 +115,10 @@ func MakePlayerButton(g *game.Game, pg *game.PlayerInGame, force bool) []InlineB
 	}
 	if pg.CanStand() {
 		if pg.IsDealer() {
-			ar = append(ar, InlineButton{Text: "Thôi", Data: "/endgame " + g.ID()})
+			// the dealer has to hit until the rule lets them reveal everyone left
+			if g.CanFinish() {
+				ar = append(ar, InlineButton{Text: "Thôi", Data: "/endgame " + g.ID()})
+			}
 		} else {
 			ar = append(ar, InlineButton{Text: "Thôi", Data: "/stand " + g.ID()})
 		}
@@ -101,8 +126,62 @@ func MakePlayerButton(g *game.Game, pg *game.PlayerInGame, force bool) []InlineB
 	return ar
 }
 
+// MakeRuleButtons lists rules to start a new game with, the preferred one goes first
+func MakeRuleButtons(preferred string) []InlineButton {
+	ids := game.Rules.SortedIDs()
+	bs := make([]InlineButton, 0, len(ids))
+	for _, id := range ids {
+		r, _ := game.Rules.Get(id)
+		if id == preferred {
+			bs = append([]InlineButton{{Text: "⭐ " + r.Name, Data: "/newgame " + id}}, bs...)
+		} else {
+			bs = append(bs, InlineButton{Text: r.Name, Data: "/newgame " + id})
+		}
+	}
+	for i := range bs {
+		bs[i].Row = i / 2
+	}
+	return bs
+}
+
 func MakeResultButtons(g *game.Game) []InlineButton {
 	return []InlineButton{
 		{Text: "Tạo ván mới", Data: "/newgame"},
 	}
 }
+
+func MakeRoomListButtons(rooms []*game.Room) []InlineButton {
+	bs := make([]InlineButton, 0, len(rooms)+1)
+	for i, r := range rooms {
+		bs = append(bs, InlineButton{Text: "Vào phòng " + r.ID(), Data: "/room join " + r.ID(), Row: i / 3})
+	}
+	bs = append(bs, InlineButton{Text: "Tạo phòng", Data: "/room new", Row: (len(rooms)-1)/3 + 1})
+	return bs
+}
+
+// MakeHistoryButtons opens the replay of the games on the page and pages through the history,
+// the date range is kept in the buttons as unix seconds
+func MakeHistoryButtons(page *game.HistoryPage, from, to time.Time) []InlineButton {
+	bs := make([]InlineButton, 0, len(page.GameIDs)+2)
+	for i, id := range page.GameIDs {
+		bs = append(bs, InlineButton{Text: fmt.Sprintf("🔍 %d", i+1), Data: "/replay " + id})
+	}
+	if page.Prev != nil {
+		bs = append(bs, InlineButton{Text: "◀️ Mới hơn", Data: historyData(fmt.Sprintf("a%d", page.Prev.After), from, to), Row: 1})
+	}
+	if page.Next != nil {
+		bs = append(bs, InlineButton{Text: "Cũ hơn ▶️", Data: historyData(fmt.Sprintf("b%d", page.Next.Before), from, to), Row: 1})
+	}
+	return bs
+}
+
+func historyData(cursor string, from, to time.Time) string {
+	return fmt.Sprintf("/history %s %d %d", cursor, unixOrZero(from), unixOrZero(to))
+}
+
+func unixOrZero(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.Unix()
+}
@@ -1,10 +1,12 @@
 package telegram
 
 import (
+	"bytes"
 	"context"
 	"fmt"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cast"
@@ -23,6 +25,12 @@ type Handler struct {
 	gameMessages sync.Map
 	dealMessages sync.Map
 
+	// pinned leaderboard
+	topChat    int64
+	topMessage *telebot.Message
+	topText    string
+	topMu      sync.Mutex
+
 	mu sync.RWMutex
 }
 
@@ -61,8 +69,16 @@ func (h *Handler) onCallback(ctx telebot.Context) error {
 		h.doEndGame(q.Message, true)
 	case "/compare":
 		h.doCompare(q.Message, false)
+	case "/revealall":
+		h.doRevealAll(q.Message)
 	case "/newgame":
 		h.doNewGame(q.Message, true)
+	case "/room":
+		h.doRoom(q.Message, true)
+	case "/history":
+		h.doHistory(q.Message, true)
+	case "/replay":
+		h.doReplay(q.Message)
 	default:
 		log.Warn().Str("cmd", ar[0]).Msg("unknown query command")
 	}
@@ -100,6 +116,12 @@ func (h *Handler) doBet(m *telebot.Message, onQuery bool) {
 		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
 		return
 	}
+	if g := h.game.CurrentGame(p.ID); g != nil && g.ID() == gameID {
+		if pg := g.FindPlayer(p.ID); pg != nil && pg.BetAmount() < amount {
+			h.sendMessage(m.Chat, fmt.Sprintf("Nhà cái không đủ tiền cân %s, cược của bạn được giảm còn %s",
+				stringer.FormatCurrency(amount), stringer.FormatCurrency(pg.BetAmount())))
+		}
+	}
 }
 
 func (h *Handler) doDeal(m *telebot.Message, onQuery bool) {
@@ -111,21 +133,26 @@ func (h *Handler) doDeal(m *telebot.Message, onQuery bool) {
 		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
 		return
 	}
+	if err := h.startGame(ctx, g); err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+	}
+}
 
-	h.broadcast(h.game.ActivePlayers(ctx), "Chốt deal:\n\n"+g.PreparingBoard(), true)
+// startGame sends the cards of the dealt game to the players and starts it
+func (h *Handler) startGame(ctx context.Context, g *game.Game) error {
+	h.broadcast(h.game.RoomPlayers(ctx, g.RoomID()), "Chốt deal:\n\n"+g.PreparingBoard(), true)
 
 	// send cards
 	for _, pg := range g.PlayersInGame() {
 		if !pg.IsDone() && !pg.IsBot() {
-			h.sendMessage(ToTelebotChat(pg.ID), "Bài của bạn: "+pg.Cards().String(false))
+			h.sendMessage(ToTelebotChat(pg.ID), "Bài của bạn: "+pg.CardsInfo())
 		}
 	}
-	h.sendMessage(ToTelebotChat(g.Dealer().ID), "Bài của bạn: "+g.Dealer().Cards().String(false, true))
+	h.sendMessage(ToTelebotChat(g.Dealer().ID), "Bài của bạn: "+g.Dealer().CardsInfo())
 
 	// start game
 	if err := h.game.Start(ctx, g); err != nil {
-		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
-		return
+		return err
 	}
 
 	if !g.Finished() {
@@ -134,7 +161,7 @@ func (h *Handler) doDeal(m *telebot.Message, onQuery bool) {
 				continue
 			}
 			msg := fmt.Sprintf("Bài của %s: %s\n%s đã thắng %s",
-				pg.Name, pg.Cards().String(false, false),
+				pg.Name, pg.CardsInfo(),
 				pg.Name, stringer.FormatCurrency(pg.Reward()))
 			h.broadcast(g.AllPlayers(), msg, false)
 		}
@@ -144,6 +171,25 @@ func (h *Handler) doDeal(m *telebot.Message, onQuery bool) {
 	if autoBotCount > 0 {
 		fakePlay(h, g, autoBotCount)
 	}
+	return nil
+}
+
+func (h *Handler) onBettingTick(g *game.Game, left time.Duration) {
+	h.onPlayerBet(g, nil)
+}
+
+func (h *Handler) onBettingTimeout(g *game.Game, dealt bool) {
+	ctx := context.TODO()
+	if !dealt {
+		h.broadcast(h.game.RoomPlayers(ctx, g.RoomID()), "⌛ Hết thời gian đặt cược, ván của `"+g.Dealer().Name+"` đã bị huỷ", true, InlineButton{
+			Text: "Tạo ván mới", Data: "/newgame",
+		})
+		return
+	}
+	if err := h.startGame(ctx, g); err != nil {
+		log.Err(err).Str("game_id", g.ID()).Msg("start game failed")
+		h.broadcast(g.Dealer(), stringer.Capitalize(err.Error()), false)
+	}
 }
 
 func (h *Handler) doCancel(m *telebot.Message, onQuery bool) {
@@ -168,11 +214,11 @@ func (h *Handler) doCancel(m *telebot.Message, onQuery bool) {
 		return
 	}
 
-	if err := h.game.CancelGame(ctx); err != nil {
+	if _, err := h.game.CancelGame(ctx, g.ID()); err != nil {
 		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
 		return
 	}
-	h.broadcast(h.game.ActivePlayers(ctx), pg.Name+" đã huỷ ván này", true, InlineButton{
+	h.broadcast(h.game.RoomPlayers(ctx, g.RoomID()), pg.Name+" đã huỷ ván này", true, InlineButton{
 		Text: "Tạo ván mới", Data: "/newgame",
 	})
 }
@@ -184,9 +230,25 @@ func (h *Handler) onNewGame(g *game.Game) {
 	d := g.Dealer()
 	h.broadcast(d.Player, msg, false, MakeDealerPrepareButtons(g)...)
 
-	// send to members
-	players := FilterPlayers(h.game.ActivePlayers(context.TODO()), d.ID)
+	// send to room members
+	players := FilterPlayers(h.game.RoomPlayers(context.TODO(), g.RoomID()), d.ID)
 	h.broadcast(players, msg, false, MakeBetButtons(g)...)
+
+	// followers outside the room are told even if they left the server
+	notified := map[string]bool{d.ID: true}
+	for _, p := range players {
+		notified[p.ID] = true
+	}
+	var followers []model.Player
+	for _, p := range h.game.Followers(context.TODO(), d.ID) {
+		if !notified[p.ID] {
+			followers = append(followers, p)
+		}
+	}
+	if len(followers) > 0 {
+		h.broadcast(followers, fmt.Sprintf("🔔 `%s` vừa mở ván mới ở phòng %s\n\n", d.Name, g.RoomID())+g.PreparingBoard(), false,
+			InlineButton{Text: "Vào phòng " + g.RoomID(), Data: "/room join " + g.RoomID()})
+	}
 }
 
 func (h *Handler) onPlayerJoin(p *model.Player) {
@@ -202,7 +264,7 @@ func (h *Handler) onPlayerBet(g *game.Game, p *game.PlayerInGame) {
 	dealer := g.Dealer()
 	h.broadcast(dealer.Player, msg, true, MakeDealerPrepareButtons(g)...)
 
-	players := FilterPlayers(h.game.ActivePlayers(context.TODO()), dealer.ID)
+	players := FilterPlayers(h.game.RoomPlayers(context.TODO(), g.RoomID()), dealer.ID)
 	h.broadcast(players, msg, true, MakeBetButtons(g)...)
 }
 
@@ -215,7 +277,7 @@ func (h *Handler) doEndGame(m *telebot.Message, onQuery bool) bool {
 
 	gameID := strings.TrimSpace(m.Payload)
 	if len(gameID) == 0 {
-		g := h.game.CurrentGame()
+		g := h.game.CurrentGame(p.ID)
 		if g == nil {
 			h.sendMessage(m.Chat, "Bạn chưa vào ván")
 			return false
@@ -288,8 +350,8 @@ func (h *Handler) doHit(m *telebot.Message, isBot bool) bool {
 		return false
 	}
 
-	if !force && pg.CanHit() && pg.Cards().Value() >= 18 {
-		h.editMessage(m, "Bài của bạn: "+pg.Cards().String(false, pg.IsDealer())+"\nBạn chắc chắn muốn rút thêm?", MakePlayerButton(g, pg, true)...)
+	if !force && pg.CanHit() && pg.Value() >= 18 {
+		h.editMessage(m, "Bài của bạn: "+pg.CardsInfo()+"\nBạn chắc chắn muốn rút thêm?", MakePlayerButton(g, pg, true)...)
 		return false
 	}
 
@@ -300,6 +362,47 @@ func (h *Handler) doHit(m *telebot.Message, isBot bool) bool {
 	return true
 }
 
+// getPlayerAndTarget returns the sender and the player named in the payload of cmd,
+// target is nil after an error message was sent
+func (h *Handler) getPlayerAndTarget(m *telebot.Message, cmd string) (p, target *model.Player) {
+	p = h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil, nil
+	}
+	if len(strings.TrimSpace(m.Payload)) == 0 {
+		h.sendMessage(m.Chat, "Cú pháp: `"+cmd+" tên_hoặc_id`")
+		return p, nil
+	}
+	target, err := h.game.LookupPlayer(h.ctx(m), m.Payload)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return p, nil
+	}
+	return p, target
+}
+
+// getPlayerAndTargetArgs returns the sender, the player named by the first word of the payload and the words after it,
+// usage is sent when their number is not within [min, max]. target is nil after an error message was sent
+func (h *Handler) getPlayerAndTargetArgs(m *telebot.Message, usage string, min, max int) (p, target *model.Player, args []string) {
+	p = h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil, nil, nil
+	}
+	ss := strings.Fields(m.Payload)
+	if len(ss) < min+1 || len(ss) > max+1 {
+		h.sendMessage(m.Chat, "Cú pháp: `"+usage+"`")
+		return p, nil, nil
+	}
+	target, err := h.game.LookupPlayer(h.ctx(m), ss[0])
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return p, nil, nil
+	}
+	return p, target, ss[1:]
+}
+
 func (h *Handler) getPlayer(m *telebot.Message, isBot ...bool) *model.Player {
 	id := cast.ToString(m.Chat.ID)
 	p, err := h.store.GetPlayerByID(h.ctx(m), id)
@@ -335,7 +438,7 @@ func (h *Handler) onPlayerStand(g *game.Game, pg *game.PlayerInGame) {
 func (h *Handler) onPlayerHit(g *game.Game, pg *game.PlayerInGame) {
 	players := FilterInGamePlayers(g.PlayersInGame(), pg.ID)
 	h.broadcast(players, "`"+pg.Name+"` vừa rút thêm 1 lá", false)
-	h.broadcast(pg, "Bài của bạn: "+pg.Cards().String(false, pg.IsDealer()), true, MakePlayerButton(g, pg, false)...)
+	h.broadcast(pg, "Bài của bạn: "+pg.CardsInfo(), true, MakePlayerButton(g, pg, false)...)
 }
 
 func (h *Handler) doCompare(m *telebot.Message, onQuery bool) {
@@ -373,7 +476,7 @@ func (h *Handler) doCompare(m *telebot.Message, onQuery bool) {
 		return
 	}
 
-	reward, err := g.Done(to, false)
+	reward, err := h.game.Compare(h.ctx(m), g, to)
 	if err != nil {
 		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
 		return
@@ -383,7 +486,7 @@ func (h *Handler) doCompare(m *telebot.Message, onQuery bool) {
 	}
 
 	msgDealer := fmt.Sprintf("Bài của %s: %s",
-		to.Name, to.Cards().String(false, false),
+		to.Name, to.CardsInfo(),
 	)
 
 	var msgPlayer string
@@ -398,7 +501,7 @@ func (h *Handler) doCompare(m *telebot.Message, onQuery bool) {
 		msgPlayer = fmt.Sprintf("🤝 Cái lật bài bạn và hoà. Bạn không bị mất gì")
 	}
 	msgPlayer += fmt.Sprintf("\nBài của cái: %s",
-		dealer.Cards().String(false, true),
+		dealer.CardsInfo(),
 	)
 
 	if onQuery {
@@ -409,17 +512,214 @@ func (h *Handler) doCompare(m *telebot.Message, onQuery bool) {
 	h.sendMessage(ToTelebotChat(to.ID), msgPlayer)
 }
 
-func (h *Handler) onGameFinish(g *game.Game) {
-	ctx := context.TODO()
+// doRevealAll compares the dealer against every remaining participant, or those with at least N cards
+func (h *Handler) doRevealAll(m *telebot.Message) {
+	ar := strings.Fields(m.Payload)
+	if len(ar) < 1 || len(ar) > 2 {
+		h.sendMessage(m.Chat, "Sai cú pháp")
+		return
+	}
+	minCards := 0
+	if len(ar) == 2 {
+		minCards = cast.ToInt(ar[1])
+	}
 
-	for _, p := range g.PlayersInGame() {
-		_, _ = h.store.AddPlayerBalance(ctx, p.Player.ID, p.Reward())
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return
+	}
+	g, dealer := h.findPlayerInGame(m, ar[0], p.ID)
+	if g == nil || dealer == nil {
+		return
+	}
+	if !dealer.IsDealer() {
+		h.sendMessage(m.Chat, "Bạn không phải nhà cái")
+		return
+	}
+	if !dealer.CanStand() {
+		h.sendMessage(m.Chat, "Bạn chưa đủ tẩy")
+		return
 	}
-	_, _ = h.store.AddPlayerBalance(ctx, g.Dealer().Player.ID, g.Dealer().Reward())
 
-	// _ = h.game.SaveToStorage()
+	reveals, err := h.game.RevealAll(h.ctx(m), g, minCards)
+	if err != nil && len(reveals) == 0 {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	if err != nil {
+		log.Err(err).Str("game_id", g.ID()).Msg("reveal all failed")
+	}
+	if h.game.CheckIfFinish(h.ctx(m), g) {
+		return
+	}
+
+	bf := bytes.NewBuffer(nil)
+	bf.WriteString(fmt.Sprintf("Cái lật bài %d người\nBài của cái: %s\n", len(reveals), dealer.CardsInfo()))
+	for _, rv := range reveals {
+		bf.WriteString(fmt.Sprintf("\n- `%s`: %s, ", rv.Player.Name, rv.Player.CardsInfo()))
+		if rv.Reward < 0 {
+			bf.WriteString(fmt.Sprintf("thắng %s", stringer.FormatCurrency(-rv.Reward)))
+		} else if rv.Reward > 0 {
+			bf.WriteString(fmt.Sprintf("thua %s", stringer.FormatCurrency(rv.Reward)))
+		} else {
+			bf.WriteString("hoà")
+		}
+	}
+	h.broadcast(dealer, bf.String(), false, MakeDealerRevealButtons(g)...)
+	h.broadcast(g.PlayersInGame(), bf.String(), false)
+}
+
+func (h *Handler) onGameFinish(g *game.Game) {
 	msg := "Kết quả ván chơi!\n\n" + g.ResultBoard()
 	h.broadcast(g.AllPlayers(), msg, false, MakeResultButtons(g)...)
+	h.refreshTop(context.TODO())
+}
+
+func (h *Handler) onLoanRepaid(r model.Repayment) {
+	borrower, err := h.store.GetPlayerByID(context.TODO(), r.Loan.BorrowerID)
+	if err != nil {
+		log.Err(err).Str("loan_id", r.Loan.ID).Msg("get borrower failed")
+		return
+	}
+	lender, err := h.store.GetPlayerByID(context.TODO(), r.Loan.LenderID)
+	if err != nil {
+		log.Err(err).Str("loan_id", r.Loan.ID).Msg("get lender failed")
+		return
+	}
+	amount, left := stringer.FormatCurrency(r.Amount), stringer.FormatCurrency(r.Loan.Outstanding())
+	h.sendChat([]model.Player{*borrower}, fmt.Sprintf("💸 Đã trừ %s tiền thắng để trả nợ `%s`, khoản vay còn %s", amount, lender.Name, left))
+	h.sendChat([]model.Player{*lender}, fmt.Sprintf("✅ `%s` đã trả bạn %s từ tiền thắng, khoản vay còn %s", borrower.Name, amount, left))
+}
+
+// loanReminderTick is how often the due loans are checked for reminders
+const loanReminderTick = 10 * time.Minute
+
+// remindLoans reminds the borrowers of the loans which are due soon or overdue every tick, it never returns
+func (h *Handler) remindLoans(tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		ctx := context.TODO()
+		for _, l := range h.game.RemindLoans(ctx, now) {
+			borrower, err := h.store.GetPlayerByID(ctx, l.BorrowerID)
+			if err != nil {
+				log.Err(err).Str("loan_id", l.ID).Msg("get borrower failed")
+				continue
+			}
+			lender := l.LenderID
+			if p, err := h.store.GetPlayerByID(ctx, l.LenderID); err == nil {
+				lender = p.Name
+			}
+			state := "đến hạn lúc " + l.DueAt.Format("02/01 15:04")
+			if l.Overdue(now) {
+				state = "đã quá hạn từ " + l.DueAt.Format("02/01 15:04")
+			}
+			h.sendChat([]model.Player{*borrower}, fmt.Sprintf("⏰ Khoản nợ %s của `%s` %s. Trả nợ bằng `/repay %s`",
+				stringer.FormatCurrency(l.Outstanding()), lender, state, l.LenderID))
+		}
+	}
+}
+
+// reliefTick is how often the balances are checked for reliefs
+const reliefTick = 10 * time.Minute
+
+// grantReliefs grants the relief to the broke players every tick and tells them, it never returns
+func (h *Handler) grantReliefs(tick time.Duration) {
+	ticker := time.NewTicker(tick)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		for _, p := range h.game.GrantReliefs(context.TODO(), now) {
+			h.sendChat([]model.Player{p}, fmt.Sprintf("🆘 Số dư của bạn đã thấp quá lâu, nhà cái cứu trợ bạn. Số dư: %s",
+				stringer.FormatCurrency(p.Balance)))
+		}
+	}
+}
+
+// SetTopChat pins the leaderboard of the day in the chat, 0 disables it
+func (h *Handler) SetTopChat(chatID int64) {
+	h.topMu.Lock()
+	defer h.topMu.Unlock()
+	h.topChat = chatID
+	h.topMessage = nil
+}
+
+// refreshTop edits the pinned leaderboard, it is sent and pinned first if there is none yet.
+// The leaderboard pinned before the bot restarted is found in the chat.
+func (h *Handler) refreshTop(ctx context.Context) {
+	h.topMu.Lock()
+	defer h.topMu.Unlock()
+	if h.topChat == 0 {
+		return
+	}
+
+	from, _ := parsePeriod("day", time.Now())
+	msg, err := h.game.Leaderboard(ctx, from, game.TopProfit)
+	if err != nil {
+		log.Err(err).Msg("get leaderboard failed")
+		return
+	}
+	if h.topMessage == nil {
+		h.topMessage = h.pinnedTop()
+	}
+	if h.topMessage != nil {
+		if msg != h.topText {
+			h.editMessageMode(h.topMessage, msg, telebot.ModeMarkdown)
+			h.topText = msg
+		}
+		return
+	}
+
+	m := h.sendMessage(&telebot.Chat{ID: h.topChat}, msg)
+	if m == nil {
+		return
+	}
+	if err := h.bot.Pin(m, telebot.Silent); err != nil {
+		log.Err(err).Int64("chat_id", h.topChat).Msg("pin leaderboard failed")
+	}
+	h.topMessage, h.topText = m, msg
+}
+
+// pinnedTop returns the message pinned in the top chat if the bot sent it, only the leaderboard is pinned by the bot
+func (h *Handler) pinnedTop() *telebot.Message {
+	chat, err := h.bot.ChatByID(h.topChat)
+	if err != nil {
+		log.Err(err).Int64("chat_id", h.topChat).Msg("get top chat failed")
+		return nil
+	}
+	pm := chat.PinnedMessage
+	if pm == nil || pm.Sender == nil || h.bot.Me == nil || pm.Sender.ID != h.bot.Me.ID {
+		return nil
+	}
+	// it is edited by its chat and id
+	pm.Chat = chat
+	return pm
+}
+
+// restoreGames resumes games which were running before the bot restarted
+func (h *Handler) restoreGames() {
+	ctx := context.TODO()
+	games, err := h.game.Restore(ctx)
+	if err != nil {
+		log.Err(err).Msg("restore games failed")
+		return
+	}
+
+	for _, g := range games {
+		h.broadcast(h.game.RoomPlayers(ctx, g.RoomID()), "♻️ Bot vừa khởi động lại, ván chơi đã được khôi phục", false)
+		switch {
+		case g.Status() == game.Betting:
+			h.onNewGame(g)
+		case g.Finished():
+			h.game.CheckIfFinish(ctx, g)
+		case g.CurrentPlaying() == nil:
+			if err := h.game.Start(ctx, g); err != nil {
+				log.Err(err).Str("game_id", g.ID()).Msg("start restored game failed")
+			}
+		default:
+			h.onPlayerPlay(g, g.CurrentPlaying())
+		}
+	}
 }
 
 func (h *Handler) onPlayerPlay(g *game.Game, pg *game.PlayerInGame) {
@@ -433,10 +733,28 @@ func (h *Handler) onPlayerPlay(g *game.Game, pg *game.PlayerInGame) {
 		// }
 		h.broadcast(g.Dealer(), "Lật bài con", false, MakeDealerRevealButtons(g)...)
 	}
-	h.broadcast(pg, "Tới lượt bạn: "+pg.Cards().String(false, pg.IsDealer()), false, MakePlayerButton(g, pg, false)...)
+	h.broadcast(pg, "Tới lượt bạn: "+pg.CardsInfo(), false, MakePlayerButton(g, pg, false)...)
 	h.broadcast(FilterInGamePlayers(g.AllPlayers(), pg.ID), "Tới lượt `"+pg.Name+"`", false)
 }
 
+func (h *Handler) onTurnWarning(g *game.Game, pg *game.PlayerInGame, left time.Duration) {
+	msg := fmt.Sprintf("⏰ Còn %s nữa là hết lượt của bạn", left)
+	if pg.IsDealer() {
+		msg = fmt.Sprintf("⏰ Còn %s nữa sẽ tự động lật bài tất cả", left)
+	}
+	h.broadcast(pg, msg, false)
+}
+
+func (h *Handler) onTurnTimeout(g *game.Game, pg *game.PlayerInGame, stood bool) {
+	msg := fmt.Sprintf("⏰ `%s` hết giờ, bị qua lượt", pg.Name)
+	if pg.IsDealer() {
+		msg = fmt.Sprintf("⏰ Nhà cái `%s` hết giờ, tự động lật bài tất cả", pg.Name)
+	} else if stood {
+		msg = fmt.Sprintf("⏰ `%s` hết giờ, tự động dừng rút", pg.Name)
+	}
+	h.broadcast(g.AllPlayers(), msg, false)
+}
+
 func (h *Handler) sendChat(receivers []model.Player, msg string) {
 	wg := sync.WaitGroup{}
 	for _, p := range receivers {
@@ -476,3 +794,53 @@ func (h *Handler) doRename(m *telebot.Message, id string, newName string) {
 	}
 	h.broadcast(h.game.AllPlayers(h.ctx(m)), "♻️ `"+oldName+"` đã đổi tên thành `"+newName+"`", false)
 }
+
+func (h *Handler) doReplay(m *telebot.Message) {
+	gameID := strings.TrimSpace(m.Payload)
+	if len(gameID) == 0 {
+		h.sendMessage(m.Chat, "Cú pháp: `/replay <game_id>`")
+		return
+	}
+	res, err := h.game.ReplayGame(h.ctx(m), gameID)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	h.sendMessage(m.Chat, res)
+}
+
+// doHistory shows a page of the player's games, the buttons page through them in place
+func (h *Handler) doHistory(m *telebot.Message, onQuery bool) {
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return
+	}
+
+	var (
+		cursor   model.Cursor
+		from, to time.Time
+		err      error
+	)
+	if onQuery {
+		cursor, from, to, err = parseHistoryQuery(m.Payload)
+	} else {
+		from, to, err = parseDateRange(m.Payload)
+	}
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+
+	page, err := h.game.PlayerHistory(h.ctx(m), p, from, to, cursor)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	buttons := MakeHistoryButtons(page, from, to)
+	if onQuery {
+		h.editMessageMode(m, page.Text, telebot.ModeMarkdown, buttons...)
+		return
+	}
+	h.sendMessage(m.Chat, page.Text, buttons...)
+}
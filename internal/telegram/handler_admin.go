@@ -9,6 +9,7 @@ import (
 	"github.com/rs/zerolog/log"
 	"gopkg.in/telebot.v3"
 
+	"github.com/psucodervn/verixilac/internal/game"
 	"github.com/psucodervn/verixilac/internal/model"
 	"github.com/psucodervn/verixilac/internal/stringer"
 )
@@ -38,7 +39,7 @@ func (h *Handler) CmdAdmin(ctx telebot.Context) error {
 		}
 		h.doRename(m, ss[1], strings.Join(ss[2:], " "))
 	case "cancel":
-		h.doAdminCancel(m)
+		h.doAdminCancel(m, p, ss[1:])
 	case "pause":
 		h.doAdminPause(m)
 	case "resume":
@@ -47,7 +48,14 @@ func (h *Handler) CmdAdmin(ctx telebot.Context) error {
 		h.doDeposit(m, p, ss[1:])
 	case "reset":
 		h.doResetBalance(m, p, ss[1:])
+	case "rule":
+		h.doAdminRule(m, p, ss[1:])
+	case "reconcile":
+		h.doReconcile(m)
+	case "faucet":
+		h.doAdminFaucet(m, p, ss[1:])
 	case "restart":
+		h.game.Snapshot(h.ctx(m))
 		os.Exit(1)
 	}
 	return nil
@@ -65,10 +73,79 @@ func (h *Handler) doResetBalance(m *telebot.Message, operator *model.Player, ss
 		return
 	}
 
-	if err := h.store.ResetBalance(h.ctx(m), balance); err != nil {
+	res, err := h.game.ResetSeason(h.ctx(m), operator, balance)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	h.broadcast(h.game.AllPlayers(h.ctx(m)), res, false)
+}
+
+func (h *Handler) doAdminFaucet(m *telebot.Message, operator *model.Player, ss []string) {
+	if len(ss) != 2 || ss[1] != "on" && ss[1] != "off" {
+		h.sendMessage(m.Chat, "Cú pháp: /admin faucet player_id on|off")
+		return
+	}
+	blocked := ss[1] == "off"
+	p, err := h.game.BlockFaucet(h.ctx(m), ss[0], blocked)
+	if err != nil {
 		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
 		return
 	}
+	log.Info().Str("operator_id", operator.ID).Str("player_id", p.ID).Bool("blocked", blocked).Msg("faucet blocked")
+	if blocked {
+		h.sendMessage(m.Chat, fmt.Sprintf("Đã chặn `%s` nhận quà và cứu trợ", p.Name))
+	} else {
+		h.sendMessage(m.Chat, fmt.Sprintf("Đã mở lại quà và cứu trợ cho `%s`", p.Name))
+	}
+}
+
+func (h *Handler) doReconcile(m *telebot.Message) {
+	res, err := h.game.ReconcileLedger(h.ctx(m))
+	if err != nil {
+		h.sendMessage(m.Chat, "Lỗi: "+err.Error())
+		return
+	}
+	h.sendMessage(m.Chat, res)
+}
+
+func (h *Handler) doAdminRule(m *telebot.Message, operator *model.Player, ss []string) {
+	usage := "Cú pháp: /admin rule set {json} | /admin rule delete rule_id | /admin rule reload"
+	if len(ss) == 0 {
+		h.sendMessage(m.Chat, usage)
+		return
+	}
+
+	ctx := h.ctx(m)
+	switch ss[0] {
+	case "set":
+		rules, err := h.game.SaveRules(ctx, operator, []byte(strings.Join(ss[1:], " ")))
+		if err != nil {
+			h.sendMessage(m.Chat, "Lỗi: "+err.Error())
+			return
+		}
+		for _, r := range rules {
+			log.Info().Str("operator_id", operator.ID).Str("rule_id", r.ID).Msg("rule saved")
+		}
+	case "delete":
+		if len(ss) != 2 {
+			h.sendMessage(m.Chat, usage)
+			return
+		}
+		if err := h.game.DeleteRule(ctx, ss[1]); err != nil {
+			h.sendMessage(m.Chat, "Lỗi: "+err.Error())
+			return
+		}
+	case "reload":
+		if err := h.game.ReloadRules(ctx); err != nil {
+			h.sendMessage(m.Chat, "Lỗi: "+err.Error())
+			return
+		}
+	default:
+		h.sendMessage(m.Chat, usage)
+		return
+	}
+	h.sendMessage(m.Chat, game.Rules.ListText())
 }
 
 func (h *Handler) doAdminPause(m *telebot.Message) {
@@ -100,7 +177,7 @@ func (h *Handler) doDeposit(m *telebot.Message, operator *model.Player, ss []str
 		return
 	}
 
-	p, err := h.game.Deposit(h.ctx(m), id, amount)
+	p, err := h.game.Deposit(h.ctx(m), operator, id, amount)
 	if err != nil {
 		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
 		return
@@ -119,11 +196,31 @@ func (h *Handler) doDeposit(m *telebot.Message, operator *model.Player, ss []str
 	h.broadcast(h.game.AllPlayers(nil), msg, false)
 }
 
-func (h *Handler) doAdminCancel(m *telebot.Message) {
+func (h *Handler) doAdminCancel(m *telebot.Message, operator *model.Player, ss []string) {
 	ctx := h.ctx(m)
-	if err := h.game.CancelGame(ctx); err != nil {
+	roomID := ""
+	if len(ss) > 0 {
+		roomID = ss[0]
+	} else if r := h.game.PlayerRoom(operator.ID); r != nil {
+		roomID = r.ID()
+	} else {
+		h.sendMessage(m.Chat, "Cú pháp: /admin cancel room_id")
+		return
+	}
+
+	r := h.game.Room(roomID)
+	if r == nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(game.ErrRoomNotFound.Error()))
+		return
+	}
+	g := r.Game()
+	if g == nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(game.ErrGameNotFound.Error()))
+		return
+	}
+	if _, err := h.game.CancelGame(ctx, g.ID()); err != nil {
 		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
 		return
 	}
-	h.broadcast(h.game.AllPlayers(ctx), "🚫 Ván chơi hiện tại đã bị huỷ, bạn có thể tạo ván mới!", false)
+	h.broadcast(h.game.RoomPlayers(ctx, roomID), "🚫 Ván chơi hiện tại đã bị huỷ, bạn có thể tạo ván mới!", false)
 }
@@ -3,12 +3,16 @@ package telegram
 import (
 	"bytes"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cast"
 	"gopkg.in/telebot.v3"
 
 	"github.com/psucodervn/verixilac/internal/game"
+	"github.com/psucodervn/verixilac/internal/model"
 	"github.com/psucodervn/verixilac/internal/stringer"
 )
 
@@ -20,11 +24,15 @@ var (
 		},
 		{
 			Text:        "room",
-			Description: "Xem thông tin phòng",
+			Description: "Xem thông tin phòng. Cú pháp: /room [list|new|join|leave|close]",
+		},
+		{
+			Text:        "players",
+			Description: "Xem danh sách người chơi",
 		},
 		{
 			Text:        "stats",
-			Description: "Xem thống kê",
+			Description: "Xem thống kê. Cú pháp: /stats [today|week|month|all] [dealer|player]",
 		},
 		{
 			Text:        "newgame",
@@ -42,13 +50,77 @@ var (
 			Text:        "leave",
 			Description: "Rời phòng chờ",
 		},
+		{
+			Text:        "setrule",
+			Description: "Chọn luật mặc định khi làm cái. Cú pháp: /setrule rule_id",
+		},
 		{
 			Text:        "pass",
 			Description: "Cho qua lượt",
 		},
+		{
+			Text:        "seed",
+			Description: "Góp seed cho ván đang chờ. Cú pháp: /seed chuỗi bất kỳ",
+		},
+		{
+			Text:        "verify",
+			Description: "Xác minh cách chia bài của ván. Cú pháp: /verify game_id",
+		},
+		{
+			Text:        "replay",
+			Description: "Xem lại diễn biến ván. Cú pháp: /replay game_id",
+		},
 		{
 			Text:        "history",
-			Description: "Xem lịch sử chơi. Cú pháp: /history",
+			Description: "Xem lịch sử chơi. Cú pháp: /history [từ ngày] [đến ngày]",
+		},
+		{
+			Text:        "statement",
+			Description: "Xem sao kê số dư",
+		},
+		{
+			Text:        "vs",
+			Description: "Xem thành tích đối đầu. Cú pháp: /vs tên_hoặc_id",
+		},
+		{
+			Text:        "top",
+			Description: "Xem bảng xếp hạng. Cú pháp: /top [day|week|month|all] [profit|games|winrate]",
+		},
+		{
+			Text:        "season",
+			Description: "Xem bảng xếp hạng mùa giải. Cú pháp: /season [số mùa]",
+		},
+		{
+			Text:        "follow",
+			Description: "Theo dõi người chơi, báo khi họ làm cái. Cú pháp: /follow tên_hoặc_id",
+		},
+		{
+			Text:        "unfollow",
+			Description: "Bỏ theo dõi người chơi. Cú pháp: /unfollow tên_hoặc_id",
+		},
+		{
+			Text:        "following",
+			Description: "Xem danh sách đang theo dõi",
+		},
+		{
+			Text:        "give",
+			Description: "Chuyển tiền cho người chơi. Cú pháp: /give tên_hoặc_id số_tiền",
+		},
+		{
+			Text:        "lend",
+			Description: "Cho vay. Cú pháp: /lend tên_hoặc_id số_tiền [hạn, ví dụ 7d] [auto]",
+		},
+		{
+			Text:        "repay",
+			Description: "Trả nợ. Cú pháp: /repay tên_hoặc_id [số_tiền]",
+		},
+		{
+			Text:        "debts",
+			Description: "Xem các khoản vay và nợ",
+		},
+		{
+			Text:        "daily",
+			Description: "Nhận quà hằng ngày, nhận liên tiếp để được thưởng thêm",
 		},
 	}
 )
@@ -71,19 +143,44 @@ func (h *Handler) Start() (err error) {
 	h.game.OnPlayerStand(h.onPlayerStand)
 	h.game.OnPlayerHit(h.onPlayerHit)
 	h.game.OnPlayerPlay(h.onPlayerPlay)
+	h.game.OnTurnWarning(h.onTurnWarning)
+	h.game.OnTurnTimeout(h.onTurnTimeout)
+	h.game.OnBettingTick(h.onBettingTick)
+	h.game.OnBettingTimeout(h.onBettingTimeout)
 	h.game.OnGameFinish(h.onGameFinish)
+	h.game.OnLoanRepaid(h.onLoanRepaid)
+	h.restoreGames()
+	go h.remindLoans(loanReminderTick)
+	go h.grantReliefs(reliefTick)
 
 	h.bot.Handle("/start", h.CmdStart)
 	h.bot.Handle("/newgame", h.CmdNewGame)
 	h.bot.Handle("/join", h.CmdJoin)
 	h.bot.Handle("/leave", h.CmdLeave)
 	h.bot.Handle("/room", h.CmdRoom)
+	h.bot.Handle("/players", h.CmdPlayers)
 	h.bot.Handle("/endgame", h.CmdEndGame)
 	h.bot.Handle("/pass", h.CmdPass)
 	h.bot.Handle("/status", h.CmdStatus)
 	h.bot.Handle("/rules", h.CmdListRules)
+	h.bot.Handle("/setrule", h.CmdSetRule)
+	h.bot.Handle("/seed", h.CmdSeed)
+	h.bot.Handle("/verify", h.CmdVerify)
+	h.bot.Handle("/replay", h.CmdReplay)
 	h.bot.Handle("/history", h.CmdHistory)
 	h.bot.Handle("/stats", h.CmdStats)
+	h.bot.Handle("/statement", h.CmdStatement)
+	h.bot.Handle("/vs", h.CmdVs)
+	h.bot.Handle("/top", h.CmdTop)
+	h.bot.Handle("/season", h.CmdSeason)
+	h.bot.Handle("/follow", h.CmdFollow)
+	h.bot.Handle("/unfollow", h.CmdUnfollow)
+	h.bot.Handle("/following", h.CmdFollowing)
+	h.bot.Handle("/give", h.CmdGive)
+	h.bot.Handle("/lend", h.CmdLend)
+	h.bot.Handle("/repay", h.CmdRepay)
+	h.bot.Handle("/debts", h.CmdDebts)
+	h.bot.Handle("/daily", h.CmdDaily)
 	h.bot.Handle("/admin", h.CmdAdmin)
 
 	h.bot.Handle(telebot.OnQuery, func(ctx telebot.Context) error {
@@ -102,7 +199,11 @@ func (h *Handler) Start() (err error) {
 			p = h.joinServer(m)
 		}
 
-		ps := FilterPlayers(h.game.AllPlayers(h.ctx(m)), p.ID)
+		ps := h.game.AllPlayers(h.ctx(m))
+		if r := h.game.PlayerRoom(p.ID); r != nil {
+			ps = h.game.RoomPlayers(h.ctx(m), r.ID())
+		}
+		ps = FilterPlayers(ps, p.ID)
 		h.sendChat(ps, "📣 `"+p.Name+":` "+m.Text)
 		return nil
 	})
@@ -131,6 +232,11 @@ func (h *Handler) CmdLeave(ctx telebot.Context) error {
 }
 
 func (h *Handler) CmdRoom(ctx telebot.Context) error {
+	h.doRoom(ctx.Message(), false)
+	return nil
+}
+
+func (h *Handler) CmdPlayers(ctx telebot.Context) error {
 	m := ctx.Message()
 	ps, err := h.store.ListPlayers(h.ctx(m))
 	if err != nil {
@@ -153,38 +259,324 @@ func (h *Handler) CmdRoom(ctx telebot.Context) error {
 
 func (h *Handler) CmdListRules(ctx telebot.Context) error {
 	m := ctx.Message()
-	h.sendMessage(m.Chat, game.RuleListText)
+	h.sendMessage(m.Chat, game.Rules.ListText())
 	return nil
 }
 
 func (h *Handler) CmdSetRule(ctx telebot.Context) error {
 	m := ctx.Message()
-	h.sendMessage(m.Chat, "Chức năng tạm thời bị vô hiệu hóa")
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+
+	ruleID := strings.TrimSpace(m.Payload)
+	if len(ruleID) == 0 {
+		h.sendMessage(m.Chat, "Cú pháp: /setrule rule_id\n\n"+game.Rules.ListText())
+		return nil
+	}
+	r, err := h.game.SetPlayerRule(h.ctx(m), p, ruleID)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, "Đã thay đổi rule của bạn thành: "+r.Name+". Tạo game mới để cảm nhận!")
 	return nil
-	// p := h.getPlayer(m)
-	// ruleID := strings.TrimSpace(m.Payload)
-	// r, ok := game.DefaultRules[ruleID]
-	// if !ok {
-	// 	h.sendMessage(m.Chat, "Không tìm thấy rule: "+ruleID)
-	// 	return
-	// }
-	// p, err := h.game.SetRule(h.ctx(m), p, ruleID)
-	// h.sendMessage(m.Chat, "Đã thay đổi rule của bạn thành: "+r.Name+". Tạo game mới để cảm nhận!")
 }
 
-func (h *Handler) CmdHistory(ctx telebot.Context) error {
+func (h *Handler) CmdSeed(ctx telebot.Context) error {
 	m := ctx.Message()
-	h.sendMessage(m.Chat, "Chức năng tạm thời bị vô hiệu hóa")
-	// p := h.getPlayer(m)
-	// res := h.game.PlayerHistory(h.ctx(m), p)
-	// h.sendMessage(m.Chat, res)
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+
+	seed := strings.TrimSpace(m.Payload)
+	if len(seed) == 0 {
+		h.sendMessage(m.Chat, "Cú pháp: `/seed <chuỗi bất kỳ>`")
+		return nil
+	}
+	g, err := h.game.AddClientSeed(h.ctx(m), p, seed)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, "Đã góp seed `"+seed+"` vào ván này")
+	h.onPlayerBet(g, nil)
+	return nil
+}
+
+func (h *Handler) CmdVerify(ctx telebot.Context) error {
+	m := ctx.Message()
+	gameID := strings.TrimSpace(m.Payload)
+	if len(gameID) == 0 {
+		h.sendMessage(m.Chat, "Cú pháp: `/verify <game_id>`")
+		return nil
+	}
+	res, err := h.game.VerifyGame(h.ctx(m), gameID)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, res)
+	return nil
+}
+
+func (h *Handler) CmdReplay(ctx telebot.Context) error {
+	h.doReplay(ctx.Message())
+	return nil
+}
+
+func (h *Handler) CmdHistory(ctx telebot.Context) error {
+	h.doHistory(ctx.Message(), false)
 	return nil
 }
 
 func (h *Handler) CmdStats(ctx telebot.Context) error {
 	m := ctx.Message()
 	p := h.getPlayer(m)
-	res := h.game.PlayerStats(h.ctx(m), p)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+	from, to, role, err := parseStatsArgs(m.Payload)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	res := h.game.PlayerStats(h.ctx(m), p, from, to, role)
+	h.sendMessage(m.Chat, res)
+	return nil
+}
+
+func (h *Handler) CmdVs(ctx telebot.Context) error {
+	m := ctx.Message()
+	p, opponent := h.getPlayerAndTarget(m, "/vs")
+	if opponent == nil {
+		return nil
+	}
+	res, err := h.game.HeadToHead(h.ctx(m), p, opponent)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, res)
+	return nil
+}
+
+func (h *Handler) CmdTop(ctx telebot.Context) error {
+	m := ctx.Message()
+	from, order, err := parseTopArgs(m.Payload)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	res, err := h.game.Leaderboard(h.ctx(m), from, order)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, res)
+	return nil
+}
+
+func (h *Handler) CmdSeason(ctx telebot.Context) error {
+	m := ctx.Message()
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+	number := 0
+	if s := strings.TrimSpace(m.Payload); len(s) > 0 {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 {
+			h.sendMessage(m.Chat, "Cú pháp: `/season [số mùa]`")
+			return nil
+		}
+		number = n
+	}
+	res, err := h.game.Season(h.ctx(m), p, number)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, res)
+	return nil
+}
+
+func (h *Handler) CmdFollow(ctx telebot.Context) error {
+	m := ctx.Message()
+	p, followee := h.getPlayerAndTarget(m, "/follow")
+	if followee == nil {
+		return nil
+	}
+	if err := h.game.Follow(h.ctx(m), p, followee); err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, fmt.Sprintf("Bạn sẽ được báo khi `%s` làm cái", followee.Name))
+	return nil
+}
+
+func (h *Handler) CmdUnfollow(ctx telebot.Context) error {
+	m := ctx.Message()
+	p, followee := h.getPlayerAndTarget(m, "/unfollow")
+	if followee == nil {
+		return nil
+	}
+	if err := h.game.Unfollow(h.ctx(m), p, followee); err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, fmt.Sprintf("Đã bỏ theo dõi `%s`", followee.Name))
+	return nil
+}
+
+func (h *Handler) CmdFollowing(ctx telebot.Context) error {
+	m := ctx.Message()
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+	res, err := h.game.Following(h.ctx(m), p)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, res)
+	return nil
+}
+
+func (h *Handler) CmdGive(ctx telebot.Context) error {
+	m := ctx.Message()
+	p, to, args := h.getPlayerAndTargetArgs(m, "/give tên_hoặc_id số_tiền", 1, 1)
+	if to == nil {
+		return nil
+	}
+	amount, err := parseAmount(args[0])
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	p, to, err = h.game.Give(h.ctx(m), p, to, amount)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+
+	log.Info().Str("sender_id", p.ID).Str("recipient_id", to.ID).Int64("amount", amount).Msg("give")
+	h.sendMessage(m.Chat, fmt.Sprintf("💸 Đã chuyển %s cho `%s`, số dư: %s",
+		stringer.FormatCurrency(amount), to.Name, stringer.FormatCurrency(p.Balance)))
+	h.sendChat([]model.Player{*to}, fmt.Sprintf("💰 `%s` đã chuyển cho bạn %s, số dư: %s",
+		p.Name, stringer.FormatCurrency(amount), stringer.FormatCurrency(to.Balance)))
+	return nil
+}
+
+func (h *Handler) CmdLend(ctx telebot.Context) error {
+	m := ctx.Message()
+	p, borrower, args := h.getPlayerAndTargetArgs(m, "/lend tên_hoặc_id số_tiền [hạn, ví dụ 7d hoặc 31/12/2024] [auto]", 1, 3)
+	if borrower == nil {
+		return nil
+	}
+	amount, due, auto, err := parseLoanArgs(args, time.Now())
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	l, err := h.game.Lend(h.ctx(m), p, borrower, amount, due, auto)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+
+	log.Info().Str("lender_id", p.ID).Str("borrower_id", borrower.ID).Str("loan_id", l.ID).Int64("amount", amount).Msg("lend")
+	terms := ""
+	if !l.DueAt.IsZero() {
+		terms += ", hạn trả " + l.DueAt.Format("02/01 15:04")
+	}
+	if l.AutoRepay {
+		terms += ", tự trừ khi thắng"
+	}
+	h.sendMessage(m.Chat, fmt.Sprintf("🤝 Đã cho `%s` vay %s%s", borrower.Name, stringer.FormatCurrency(amount), terms))
+	h.sendChat([]model.Player{*borrower}, fmt.Sprintf("🤝 `%s` đã cho bạn vay %s%s. Trả nợ bằng `/repay %s`",
+		p.Name, stringer.FormatCurrency(amount), terms, p.ID))
+	return nil
+}
+
+func (h *Handler) CmdRepay(ctx telebot.Context) error {
+	m := ctx.Message()
+	p, lender, args := h.getPlayerAndTargetArgs(m, "/repay tên_hoặc_id [số_tiền]", 0, 1)
+	if lender == nil {
+		return nil
+	}
+	amount := int64(0)
+	if len(args) > 0 {
+		var err error
+		if amount, err = parseAmount(args[0]); err != nil {
+			h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+			return nil
+		}
+	}
+	paid, owed, err := h.game.Repay(h.ctx(m), p, lender, amount)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+
+	log.Info().Str("borrower_id", p.ID).Str("lender_id", lender.ID).Int64("amount", paid).Msg("repay")
+	h.sendMessage(m.Chat, fmt.Sprintf("✅ Đã trả `%s` %s, còn nợ %s", lender.Name, stringer.FormatCurrency(paid), stringer.FormatCurrency(owed)))
+	h.sendChat([]model.Player{*lender}, fmt.Sprintf("✅ `%s` đã trả bạn %s, còn nợ %s", p.Name, stringer.FormatCurrency(paid), stringer.FormatCurrency(owed)))
+	return nil
+}
+
+func (h *Handler) CmdDebts(ctx telebot.Context) error {
+	m := ctx.Message()
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+	res, err := h.game.Debts(h.ctx(m), p)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, res)
+	return nil
+}
+
+func (h *Handler) CmdDaily(ctx telebot.Context) error {
+	m := ctx.Message()
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+	res, err := h.game.ClaimDaily(h.ctx(m), p)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
+	h.sendMessage(m.Chat, res)
+	return nil
+}
+
+func (h *Handler) CmdStatement(ctx telebot.Context) error {
+	m := ctx.Message()
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+	res, err := h.game.PlayerStatement(h.ctx(m), p, 20)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return nil
+	}
 	h.sendMessage(m.Chat, res)
 	return nil
 }
@@ -200,8 +592,9 @@ func (h *Handler) CmdStatus(ctx telebot.Context) error {
 		"- ID: `%s`\n"+
 		"- Name: %s\n"+
 		"- Balance: `%s`\n"+
-		"- Status: %s\n",
-		p.ID, p.Name, stringer.FormatCurrency(p.Balance), p.UserStatus)
+		"- Status: %s\n"+
+		"- Rule: %s\n",
+		p.ID, p.Name, stringer.FormatCurrency(p.Balance), p.UserStatus, p.RuleID)
 	h.sendMessage(m.Chat, msg)
 
 	return nil
@@ -227,11 +620,24 @@ func (h *Handler) doNewGame(m *telebot.Message, onQuery bool) {
 		return
 	}
 
-	g, err := h.game.NewGame(p)
+	// let the dealer choose the rule first
+	ruleID := strings.TrimSpace(m.Payload)
+	if len(ruleID) == 0 {
+		h.sendMessage(m.Chat, "Chọn luật cho ván mới:\n\n"+game.Rules.ListText(), MakeRuleButtons(p.RuleID)...)
+		return
+	}
+	if onQuery {
+		_, _ = h.bot.EditReplyMarkup(m, nil)
+	}
+
+	g, err := h.game.NewGame(p, ruleID)
 	if err != nil {
 		h.sendMessage(m.Chat, "Không thể tạo ván mới: "+err.Error())
 		return
 	}
+	if _, err := h.game.SetPlayerRule(h.ctx(m), p, ruleID); err != nil {
+		log.Err(err).Str("rule_id", ruleID).Msg("save preferred rule failed")
+	}
 
 	if autoBotCount > 0 {
 		fakeBet(h.ctx(m), h, g, autoBotCount)
@@ -239,9 +645,13 @@ func (h *Handler) doNewGame(m *telebot.Message, onQuery bool) {
 }
 
 func (h *Handler) CmdPass(ctx telebot.Context) error {
-	// p := h.getPlayer(m)
 	m := ctx.Message()
-	pg, err := h.game.PlayerPass(h.ctx(m))
+	p := h.getPlayer(m)
+	if p == nil {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return nil
+	}
+	pg, err := h.game.PlayerPass(h.ctx(m), p)
 	if err != nil {
 		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
 		return err
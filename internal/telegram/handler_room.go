@@ -0,0 +1,162 @@
+package telegram
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cast"
+	"gopkg.in/telebot.v3"
+
+	"github.com/psucodervn/verixilac/internal/game"
+	"github.com/psucodervn/verixilac/internal/model"
+	"github.com/psucodervn/verixilac/internal/stringer"
+)
+
+func (h *Handler) doRoom(m *telebot.Message, onQuery bool) {
+	p := h.getPlayer(m)
+	if p == nil || !p.IsActive() {
+		h.sendMessage(m.Chat, "Bạn chưa vào sòng")
+		return
+	}
+
+	ss := strings.Fields(m.Payload)
+	if len(ss) == 0 {
+		if r := h.game.PlayerRoom(p.ID); r != nil {
+			h.sendMessage(m.Chat, h.game.RoomInfo(h.ctx(m), r))
+		} else {
+			h.doListRooms(m)
+		}
+		return
+	}
+
+	switch ss[0] {
+	case "list":
+		h.doListRooms(m)
+	case "new":
+		h.doCreateRoom(m, p)
+	case "join":
+		if len(ss) != 2 {
+			h.sendMessage(m.Chat, "Cú pháp: /room join room_id")
+			return
+		}
+		h.doJoinRoom(m, p, ss[1])
+	case "leave":
+		h.doLeaveRoom(m, p)
+	case "close":
+		h.doCloseRoom(m, p)
+	case "maxbet", "timeout", "window", "rule":
+		if len(ss) != 2 {
+			h.sendMessage(m.Chat, "Cú pháp: /room "+ss[0]+" value")
+			return
+		}
+		h.doUpdateRoom(m, p, ss[0], ss[1])
+	default:
+		h.sendMessage(m.Chat, "Cú pháp: /room [list|new|join|leave|close|maxbet|timeout|window|rule]")
+	}
+}
+
+func (h *Handler) doListRooms(m *telebot.Message) {
+	rooms := h.game.Rooms()
+	if len(rooms) == 0 {
+		h.sendMessage(m.Chat, "Chưa có phòng nào", InlineButton{Text: "Tạo phòng", Data: "/room new"})
+		return
+	}
+
+	bf := bytes.NewBuffer(nil)
+	bf.WriteString("Danh sách phòng:\n")
+	for _, r := range rooms {
+		bf.WriteString(fmt.Sprintf("- `%s`: %d người, cược tối đa %s", r.ID(), len(r.Members()), stringer.FormatCurrency(r.MaxBet())))
+		if r.Game() != nil {
+			bf.WriteString(" (đang chơi)")
+		}
+		bf.WriteString("\n")
+	}
+	h.sendMessage(m.Chat, bf.String(), MakeRoomListButtons(rooms)...)
+}
+
+func (h *Handler) doCreateRoom(m *telebot.Message, p *model.Player) {
+	r, err := h.game.CreateRoom(h.ctx(m), p)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	h.sendMessage(m.Chat, "Đã tạo phòng `"+r.ID()+"`\n\n"+h.game.RoomInfo(h.ctx(m), r), InlineButton{Text: "Tạo ván mới", Data: "/newgame"})
+	h.broadcast(FilterPlayers(h.game.ActivePlayers(h.ctx(m)), p.ID), "🏠 `"+p.Name+"` vừa mở phòng `"+r.ID()+"`", false,
+		InlineButton{Text: "Vào phòng " + r.ID(), Data: "/room join " + r.ID()})
+}
+
+func (h *Handler) doJoinRoom(m *telebot.Message, p *model.Player, roomID string) {
+	r, err := h.game.JoinRoom(h.ctx(m), p, roomID)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	h.sendMessage(m.Chat, "Bạn đã vào phòng `"+r.ID()+"`\n\n"+h.game.RoomInfo(h.ctx(m), r))
+	h.broadcast(FilterPlayers(h.game.RoomPlayers(h.ctx(m), r.ID()), p.ID), "`"+p.Name+"` vừa vào phòng", false)
+}
+
+func (h *Handler) doLeaveRoom(m *telebot.Message, p *model.Player) {
+	r, err := h.game.LeaveRoom(h.ctx(m), p)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	h.sendMessage(m.Chat, "Bạn đã rời phòng `"+r.ID()+"`")
+	h.broadcast(h.game.RoomPlayers(h.ctx(m), r.ID()), "`"+p.Name+"` vừa rời phòng", false)
+}
+
+func (h *Handler) doCloseRoom(m *telebot.Message, p *model.Player) {
+	members := h.game.RoomPlayers(h.ctx(m), h.roomID(p))
+	r, err := h.game.CloseRoom(h.ctx(m), p)
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	h.broadcast(members, "🚪 Phòng `"+r.ID()+"` đã đóng", false)
+}
+
+func (h *Handler) doUpdateRoom(m *telebot.Message, p *model.Player, field string, value string) {
+	r, err := h.game.UpdateRoom(h.ctx(m), p, func(r *game.Room) error {
+		switch field {
+		case "maxbet":
+			v, err := cast.ToUint64E(value)
+			if err != nil || v == 0 {
+				return fmt.Errorf("số cược không hợp lệ")
+			}
+			r.SetMaxBet(v)
+		case "timeout":
+			v, err := time.ParseDuration(value)
+			if err != nil || v <= 0 {
+				return fmt.Errorf("thời gian không hợp lệ, ví dụ: 30s, 1m")
+			}
+			r.SetTimeout(v)
+		case "window":
+			v, err := time.ParseDuration(value)
+			if err != nil || v < 0 {
+				return fmt.Errorf("thời gian không hợp lệ, ví dụ: 30s, 1m, 0 để tự chia bài")
+			}
+			r.SetBettingWindow(v)
+		case "rule":
+			rule, ok := game.Rules.Get(value)
+			if !ok {
+				return fmt.Errorf("không tìm thấy rule: %s", value)
+			}
+			r.SetRule(&rule)
+		}
+		return nil
+	})
+	if err != nil {
+		h.sendMessage(m.Chat, stringer.Capitalize(err.Error()))
+		return
+	}
+	h.broadcast(h.game.RoomPlayers(h.ctx(m), r.ID()), "⚙️ `"+p.Name+"` đã cập nhật phòng\n\n"+h.game.RoomInfo(h.ctx(m), r), false)
+}
+
+func (h *Handler) roomID(p *model.Player) string {
+	if r := h.game.PlayerRoom(p.ID); r != nil {
+		return r.ID()
+	}
+	return ""
+}
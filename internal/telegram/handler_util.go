@@ -2,8 +2,12 @@ package telegram
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/rs/zerolog/log"
 	"gopkg.in/telebot.v3"
@@ -43,7 +47,14 @@ func (h *Handler) sendMessage(chat *telebot.Chat, msg string, buttons ...InlineB
 }
 
 func (h *Handler) editMessage(m *telebot.Message, msg string, buttons ...InlineButton) *telebot.Message {
-	options := &telebot.SendOptions{}
+	return h.editMessageMode(m, msg, telebot.ModeDefault, buttons...)
+}
+
+// editMessageMode edits the message like editMessage, with msg parsed in the parse mode
+func (h *Handler) editMessageMode(m *telebot.Message, msg string, mode telebot.ParseMode, buttons ...InlineButton) *telebot.Message {
+	options := &telebot.SendOptions{
+		ParseMode: mode,
+	}
 	if len(buttons) > 0 {
 		options.ReplyMarkup = &telebot.ReplyMarkup{
 			InlineKeyboard: ToTelebotInlineButtons(buttons),
@@ -144,3 +155,175 @@ func (h *Handler) findPlayerInGame(m *telebot.Message, gameID string, playerID s
 	}
 	return g, pg
 }
+
+var dateLayouts = []string{"02/01/2006", "2006-01-02"}
+
+// parsePeriod returns the start of today, this week or this month, zero time for all
+func parsePeriod(s string, now time.Time) (from time.Time, ok bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(s) {
+	case "today", "day":
+		return today, true
+	case "week":
+		// weeks start on Monday
+		return today.AddDate(0, 0, -(int(today.Weekday())+6)%7), true
+	case "month":
+		return today.AddDate(0, 0, 1-today.Day()), true
+	case "all":
+		return time.Time{}, true
+	}
+	return time.Time{}, false
+}
+
+// parseTopArgs parses "[day|week|month|all] [profit|games|winrate]", the default is all time by profit
+func parseTopArgs(payload string) (from time.Time, order game.TopOrder, err error) {
+	for _, arg := range strings.Fields(payload) {
+		switch strings.ToLower(arg) {
+		case "profit":
+			order = game.TopProfit
+			continue
+		case "games":
+			order = game.TopGames
+			continue
+		case "winrate":
+			order = game.TopWinRate
+			continue
+		}
+		t, ok := parsePeriod(arg, time.Now())
+		if !ok {
+			return from, order, fmt.Errorf("cú pháp: /top [day|week|month|all] [profit|games|winrate]")
+		}
+		from = t
+	}
+	return from, order, nil
+}
+
+// parseStatsArgs parses "[today|week|month|all] [dealer|player]", a date range is also accepted instead of the period
+func parseStatsArgs(payload string) (from, to time.Time, role model.StatsRole, err error) {
+	var dates []string
+	for _, arg := range strings.Fields(payload) {
+		switch strings.ToLower(arg) {
+		case "dealer":
+			role = model.StatsDealer
+			continue
+		case "player":
+			role = model.StatsParticipant
+			continue
+		}
+		if t, ok := parsePeriod(arg, time.Now()); ok {
+			from = t
+			continue
+		}
+		dates = append(dates, arg)
+	}
+	if len(dates) > 0 {
+		from, to, err = parseDateRange(strings.Join(dates, " "))
+	}
+	return from, to, role, err
+}
+
+// parseDateRange parses "[from] [to]" dates, to is inclusive so the returned range is [from, to+1 day)
+func parseDateRange(payload string) (from, to time.Time, err error) {
+	args := strings.Fields(payload)
+	if len(args) > 2 {
+		return from, to, fmt.Errorf("cú pháp: [từ ngày] [đến ngày], ví dụ 01/02/2024 15/02/2024")
+	}
+	dates := make([]time.Time, len(args))
+	for i, arg := range args {
+		if dates[i], err = parseDate(arg); err != nil {
+			return from, to, err
+		}
+	}
+	if len(dates) > 0 {
+		from = dates[0]
+	}
+	if len(dates) > 1 {
+		to = dates[1].AddDate(0, 0, 1)
+	}
+	return from, to, nil
+}
+
+func parseDate(s string) (time.Time, error) {
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("ngày không hợp lệ: %s", s)
+}
+
+// parseHistoryQuery parses the payload of the history buttons: the cursor, a or b followed by
+// a record key, then the date range in unix seconds, 0 for no limit
+func parseHistoryQuery(payload string) (cursor model.Cursor, from, to time.Time, err error) {
+	args := strings.Fields(payload)
+	if len(args) != 3 || len(args[0]) < 2 {
+		return cursor, from, to, fmt.Errorf("sai cú pháp")
+	}
+	key, err := strconv.ParseUint(args[0][1:], 10, 64)
+	if err != nil {
+		return cursor, from, to, fmt.Errorf("sai cú pháp")
+	}
+	switch args[0][0] {
+	case 'a':
+		cursor.After = key
+	case 'b':
+		cursor.Before = key
+	default:
+		return cursor, from, to, fmt.Errorf("sai cú pháp")
+	}
+	for i, t := range []*time.Time{&from, &to} {
+		sec, err := strconv.ParseInt(args[i+1], 10, 64)
+		if err != nil {
+			return cursor, from, to, fmt.Errorf("sai cú pháp")
+		}
+		if sec > 0 {
+			*t = time.Unix(sec, 0)
+		}
+	}
+	return cursor, from, to, nil
+}
+
+// parseAmount parses a positive amount of money
+func parseAmount(s string) (int64, error) {
+	amount, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || amount <= 0 {
+		return 0, fmt.Errorf("số tiền không hợp lệ: %s", s)
+	}
+	return amount, nil
+}
+
+// parseDue parses a due date, either a number of days like 7d or a date which is due at its end
+func parseDue(s string, now time.Time) (time.Time, error) {
+	if lower := strings.ToLower(s); strings.HasSuffix(lower, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(lower, "d"))
+		if err != nil || days <= 0 {
+			return time.Time{}, fmt.Errorf("hạn trả không hợp lệ: %s", s)
+		}
+		return now.AddDate(0, 0, days), nil
+	}
+	t, err := parseDate(s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("hạn trả không hợp lệ: %s", s)
+	}
+	return t.AddDate(0, 0, 1), nil
+}
+
+// parseLoanArgs parses "số_tiền [hạn] [auto]" following the borrower of /lend
+func parseLoanArgs(args []string, now time.Time) (amount int64, due time.Time, auto bool, err error) {
+	if len(args) == 0 || len(args) > 3 {
+		return 0, due, false, fmt.Errorf("cú pháp: /lend tên_hoặc_id số_tiền [hạn, ví dụ 7d hoặc 31/12/2024] [auto]")
+	}
+	if amount, err = parseAmount(args[0]); err != nil {
+		return 0, due, false, err
+	}
+	for _, arg := range args[1:] {
+		if strings.EqualFold(arg, "auto") {
+			auto = true
+			continue
+		}
+		if due, err = parseDue(arg, now); err != nil {
+			return 0, due, false, err
+		}
+	}
+	return amount, due, auto, nil
+}